@@ -0,0 +1,406 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"portfolio-site/models"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/beego/beego/v2/server/web"
+	"github.com/beego/beego/v2/server/web/context"
+)
+
+// --- Admin Access ---
+
+// AdminAuth guards /admin/* with HTTP basic auth. Credentials come from
+// ADMIN_USER (default "admin") and ADMIN_PASSWORD; with no password set the
+// admin area is disabled entirely.
+func AdminAuth(ctx *context.Context) {
+    password := os.Getenv("ADMIN_PASSWORD")
+    if password == "" {
+        ctx.ResponseWriter.WriteHeader(404)
+        ctx.WriteString("Not Found")
+        return
+    }
+
+    username := os.Getenv("ADMIN_USER")
+    if username == "" {
+        username = "admin"
+    }
+
+    user, pass, ok := ctx.Request.BasicAuth()
+    userOk := subtle.ConstantTimeCompare([]byte(user), []byte(username)) == 1
+    passOk := subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1
+    if !ok || !userOk || !passOk {
+        ctx.Output.Header("WWW-Authenticate", `Basic realm="admin"`)
+        ctx.ResponseWriter.WriteHeader(401)
+        ctx.WriteString("Unauthorized")
+        return
+    }
+
+    // Browsers resend basic auth credentials on cross-site form posts, so
+    // changes must come from our own pages.
+    if ctx.Input.Method() != "GET" && !sameOrigin(ctx) {
+        ctx.ResponseWriter.WriteHeader(403)
+        ctx.WriteString("Forbidden")
+    }
+}
+
+// sameOrigin reports whether the request's Origin (or, failing that,
+// Referer) is this host.
+func sameOrigin(ctx *context.Context) bool {
+    source := ctx.Input.Header("Origin")
+    if source == "" {
+        source = ctx.Input.Refer()
+    }
+    u, err := url.Parse(source)
+    return err == nil && u.Host != "" && u.Host == ctx.Request.Host
+}
+
+// --- Admin Pages ---
+
+func (c *PortfolioController) setAdminPage(title string) {
+    c.Data["Title"] = title
+    c.Data["Name"] = "Jake Morgan"
+    c.Data["Page"] = "admin"
+    c.Data["Email"] = "jmorgan3142001@gmail.com"
+    c.Data["GithubLink"] = "https://github.com/jmorgan3142001"
+    c.Data["LinkedinLink"] = "https://www.linkedin.com/in/jake-morgan-/"
+    c.Layout = "layout.html"
+}
+
+// AdminAnalytics shows per-challenge attempt and pass statistics
+func (c *PortfolioController) AdminAnalytics() {
+    c.setAdminPage("Admin // Analytics")
+
+    c.Data["Challenges"] = models.GetAllChallenges()
+    c.Data["Stats"] = models.GetChallengeStats()
+
+    c.TplName = "admin_analytics.html"
+}
+
+// --- Challenge Authoring ---
+// Challenges seeded from challenges/ follow their file until edited here.
+// Saving fields or test cases (anything but the published flag) marks the
+// row admin-owned, and the seeder leaves it alone from then on.
+
+// AdminChallenges lists every challenge, published or not
+func (c *PortfolioController) AdminChallenges() {
+    c.setAdminPage("Admin // Challenges")
+
+    challenges := models.GetAllChallenges()
+    counts := make(map[int]int)
+    for _, ch := range challenges {
+        counts[ch.Id] = len(models.GetTestCases(ch.Id))
+    }
+    c.Data["Challenges"] = challenges
+    c.Data["CaseCounts"] = counts
+    c.readAdminFlash()
+
+    c.TplName = "admin_challenges.html"
+}
+
+// AdminNewChallenge shows an empty challenge form
+func (c *PortfolioController) AdminNewChallenge() {
+    c.showChallengeForm(models.Challenge{Type: models.TypeCode, Language: "python", Difficulty: "Easy"})
+}
+
+// AdminCreateChallenge saves a new challenge, unpublished unless ticked
+func (c *PortfolioController) AdminCreateChallenge() {
+    var challenge models.Challenge
+    c.challengeFromForm(&challenge)
+    if challenge.Slug == "" {
+        challenge.Slug = models.SlugFromTitle(challenge.Title)
+    }
+
+    if err := models.ValidateChallenge(challenge); err != nil {
+        c.Data["Error"] = err.Error()
+        c.showChallengeForm(challenge)
+        return
+    }
+    if _, err := models.ReviseChallenge(&challenge, nil); err != nil {
+        c.Data["Error"] = err.Error()
+        c.showChallengeForm(challenge)
+        return
+    }
+
+    c.redirectToChallenge(challenge.Id, "Challenge created. Add test cases, then dry-run it before publishing.")
+}
+
+// AdminEditChallenge shows a challenge with its current test cases
+func (c *PortfolioController) AdminEditChallenge() {
+    challenge, ok := c.adminChallenge()
+    if !ok {
+        return
+    }
+    c.readAdminFlash()
+    c.showChallengeForm(challenge)
+}
+
+// AdminUpdateChallenge saves the challenge fields, keeping its test cases
+func (c *PortfolioController) AdminUpdateChallenge() {
+    challenge, ok := c.adminChallenge()
+    if !ok {
+        return
+    }
+    before := challenge
+    c.challengeFromForm(&challenge)
+
+    // Publishing alone leaves a seeded challenge following its file
+    before.Published = challenge.Published
+    if !reflect.DeepEqual(before, challenge) {
+        challenge.AdminOwned = true
+    }
+
+    if err := models.ValidateChallenge(challenge); err != nil {
+        c.Data["Error"] = err.Error()
+        c.showChallengeForm(challenge)
+        return
+    }
+    c.reviseChallenge(challenge, models.GetTestCases(challenge.Id), "Challenge saved.")
+}
+
+// AdminDeleteChallenge removes a challenge and everything recorded against it
+func (c *PortfolioController) AdminDeleteChallenge() {
+    challenge, ok := c.adminChallenge()
+    if !ok {
+        return
+    }
+    flash := web.NewFlash()
+    if err := models.DeleteChallenge(challenge.Id); err != nil {
+        flash.Error("%s", err.Error())
+    } else {
+        flash.Notice("Deleted %s.", challenge.Title)
+    }
+    flash.Store(&c.Controller)
+    c.Redirect("/admin/challenges", 302)
+}
+
+// AdminAddCase appends a test case
+func (c *PortfolioController) AdminAddCase() {
+    challenge, ok := c.adminChallenge()
+    if !ok {
+        return
+    }
+    var tc models.TestCase
+    c.caseFromForm(&tc)
+    cases := append(models.GetTestCases(challenge.Id), tc)
+    challenge.AdminOwned = true
+    c.reviseChallenge(challenge, cases, "Test case added.")
+}
+
+// AdminUpdateCase replaces one test case
+func (c *PortfolioController) AdminUpdateCase() {
+    challenge, ok := c.adminChallenge()
+    if !ok {
+        return
+    }
+    cases := models.GetTestCases(challenge.Id)
+    i := c.caseIndex(cases)
+    if i < 0 {
+        c.Abort("404")
+        return
+    }
+    c.caseFromForm(&cases[i])
+    challenge.AdminOwned = true
+    c.reviseChallenge(challenge, cases, "Test case saved.")
+}
+
+// AdminDeleteCase removes one test case
+func (c *PortfolioController) AdminDeleteCase() {
+    challenge, ok := c.adminChallenge()
+    if !ok {
+        return
+    }
+    cases := models.GetTestCases(challenge.Id)
+    i := c.caseIndex(cases)
+    if i < 0 {
+        c.Abort("404")
+        return
+    }
+    cases = append(cases[:i], cases[i+1:]...)
+    challenge.AdminOwned = true
+    c.reviseChallenge(challenge, cases, "Test case removed.")
+}
+
+// AdminDryRun grades the reference solution or starter code against the
+// saved cases and shows the full, unredacted output
+func (c *PortfolioController) AdminDryRun() {
+    challenge, ok := c.adminChallenge()
+    if !ok {
+        return
+    }
+
+    target := c.GetString("target")
+    if target != "starter" {
+        target = "reference"
+    }
+
+    report, err := models.DryRun(codeExecutor, challenge, models.GetTestCases(challenge.Id), target)
+    if err != nil {
+        c.Data["Error"] = "Dry run failed: " + err.Error()
+    } else {
+        c.Data["DryRun"] = report
+        c.Data["DryRunTarget"] = target
+    }
+    c.showChallengeForm(challenge)
+}
+
+// --- Authoring Helpers ---
+
+// adminChallenge loads the challenge named by :id, published or not
+func (c *PortfolioController) adminChallenge() (models.Challenge, bool) {
+    id, _ := strconv.Atoi(c.Ctx.Input.Param(":id"))
+    challenge, err := models.GetChallengeById(id)
+    if err != nil {
+        c.Abort("404")
+        return challenge, false
+    }
+    return challenge, true
+}
+
+func (c *PortfolioController) showChallengeForm(challenge models.Challenge) {
+    if challenge.Id == 0 {
+        c.setAdminPage("Admin // New Challenge")
+    } else {
+        c.setAdminPage("Admin // " + challenge.Title)
+        c.Data["Cases"] = models.GetTestCases(challenge.Id)
+    }
+    c.Data["Challenge"] = challenge
+    c.Data["ChoicesText"] = strings.Join(challenge.ChoiceList(), "\n")
+    c.Data["Languages"] = append(models.GetLanguages(), models.Language{Name: "sql"})
+    c.Data["Types"] = []string{models.TypeCode, models.TypeMCQ, models.TypeSQL, models.TypePredict}
+    c.Data["NewCase"] = models.TestCase{Comparator: models.CompareExact, Class: models.ClassCorrectness, Weight: 1}
+    c.TplName = "admin_challenge.html"
+}
+
+// reviseChallenge saves the challenge with cases as its new full case list,
+// bumping the version if graded content changed
+func (c *PortfolioController) reviseChallenge(challenge models.Challenge, cases []models.TestCase, notice string) {
+    bumped, err := models.ReviseChallenge(&challenge, cases)
+    if err != nil {
+        c.Data["Error"] = err.Error()
+        c.showChallengeForm(challenge)
+        return
+    }
+    if bumped {
+        notice += fmt.Sprintf(" Now version %d.", challenge.Version)
+    }
+    c.redirectToChallenge(challenge.Id, notice)
+}
+
+func (c *PortfolioController) redirectToChallenge(id int, notice string) {
+    flash := web.NewFlash()
+    flash.Notice("%s", notice)
+    flash.Store(&c.Controller)
+    c.Redirect(fmt.Sprintf("/admin/challenges/%d", id), 302)
+}
+
+func (c *PortfolioController) readAdminFlash() {
+    flash := web.ReadFromRequest(&c.Controller)
+    c.Data["Notice"] = flash.Data["notice"]
+    if msg, ok := flash.Data["error"]; ok {
+        c.Data["Error"] = msg
+    }
+}
+
+// caseIndex finds the case named by :case in cases, or returns -1
+func (c *PortfolioController) caseIndex(cases []models.TestCase) int {
+    id, _ := strconv.Atoi(c.Ctx.Input.Param(":case"))
+    for i, tc := range cases {
+        if tc.Id == id {
+            return i
+        }
+    }
+    return -1
+}
+
+func (c *PortfolioController) challengeFromForm(ch *models.Challenge) {
+    ch.Slug = strings.TrimSpace(c.GetString("slug"))
+    ch.Title = strings.TrimSpace(c.GetString("title"))
+    ch.Description = c.GetString("description")
+    ch.InputHint = c.GetString("input_hint")
+    ch.FunctionName = strings.TrimSpace(c.GetString("function_name"))
+    ch.Difficulty = c.GetString("difficulty")
+    ch.Category = c.GetString("category")
+    ch.Type = strings.ToUpper(c.GetString("type"))
+    ch.Language = c.GetString("language")
+    ch.StarterCode = c.GetString("starter_code")
+    ch.ReferenceSolution = c.GetString("reference_solution")
+    ch.Generator = c.GetString("generator")
+    ch.PropertyCases, _ = c.GetInt("property_cases", 0)
+    ch.Answer = c.GetString("answer")
+    ch.Fixture = c.GetString("fixture")
+    ch.Published, _ = c.GetBool("published", false)
+
+    var choices []string
+    for _, line := range strings.Split(c.GetString("choices"), "\n") {
+        if line = strings.TrimSpace(line); line != "" {
+            choices = append(choices, line)
+        }
+    }
+    ch.Choices = ""
+    if len(choices) > 0 {
+        b, _ := json.Marshal(choices)
+        ch.Choices = string(b)
+    }
+}
+
+func (c *PortfolioController) caseFromForm(tc *models.TestCase) {
+    tc.InputArgs = c.GetString("input_args")
+    tc.ExpectedOutput = c.GetString("expected_output")
+    tc.Comparator = c.GetString("comparator", models.CompareExact)
+    tc.CompareArg = c.GetString("compare_arg")
+    tc.Hidden, _ = c.GetBool("hidden", false)
+    tc.Weight, _ = c.GetFloat("weight", 1)
+    tc.Class = c.GetString("class", models.ClassCorrectness)
+    tc.BudgetMs, _ = c.GetFloat("budget_ms", 0)
+    if tc.Weight <= 0 {
+        tc.Weight = 1
+    }
+}
+
+// --- Access Log Moderation ---
+
+// AdminLogs lists guestbook entries with one status (pending by default)
+// and the latest moderation actions
+func (c *PortfolioController) AdminLogs() {
+    c.setAdminPage("Admin // Moderation")
+
+    status := c.GetString("status", models.LogPending)
+    switch status {
+    case models.LogPending, models.LogFlagged, models.LogApproved, models.LogRejected:
+    default:
+        status = models.LogPending
+    }
+
+    c.Data["Status"] = status
+    c.Data["Statuses"] = []string{models.LogPending, models.LogFlagged, models.LogApproved, models.LogRejected}
+    c.Data["Counts"] = models.CountByStatus()
+    c.Data["Logs"] = models.GetModerationQueue(status)
+    c.Data["Actions"] = models.GetModerationActions(50)
+    c.readAdminFlash()
+
+    c.TplName = "admin_logs.html"
+}
+
+// AdminModerateLog approves, rejects or deletes one entry
+func (c *PortfolioController) AdminModerateLog() {
+    id, _ := strconv.Atoi(c.Ctx.Input.Param(":id"))
+    action := c.Ctx.Input.Param(":action")
+    admin, _, _ := c.Ctx.Request.BasicAuth()
+
+    flash := web.NewFlash()
+    if err := models.ModerateLog(id, action, admin); err != nil {
+        flash.Error("%s", err.Error())
+    } else {
+        flash.Notice("Entry #%d: %s done.", id, action)
+    }
+    flash.Store(&c.Controller)
+    c.Redirect("/admin/logs?status="+url.QueryEscape(c.GetString("status", models.LogPending)), 302)
+}
@@ -1,22 +1,29 @@
 package controllers
 
 import (
-	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"portfolio-site/models"
+	"regexp"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/beego/beego/v2/server/web"
 )
 
 var (
-    ipRateLimiter = make(map[string]time.Time)
-    mutex         sync.Mutex
+    // Backend for /challenges/run, selected by `executor` in app.conf
+    codeExecutor = models.NewExecutor()
+    runQueue     = models.NewJobQueue(
+        codeExecutor,
+        web.AppConfig.DefaultInt("run_workers", 2),
+        web.AppConfig.DefaultInt("run_max_inflight", 32),
+    )
 )
 
 // --- Controller Definition ---
@@ -99,7 +106,9 @@ func (c *PortfolioController) Get() {
     c.Data["TechSpecs"] = models.GetTechSpecs()
     c.Data["Experience"] = models.GetExperience()
     c.Data["Projects"] = models.GetProjects()
-    c.Data["Logs"] = models.GetAccessLogs()
+    logs := models.GetAccessLogs()
+    c.Data["Logs"] = logs.Logs
+    c.Data["LogsCursor"] = logs.NextCursor
 
     // Render Configuration
     c.Layout = "layout.html"
@@ -107,17 +116,10 @@ func (c *PortfolioController) Get() {
 }
 
 func (c *PortfolioController) SubmitLog() {
-    ip := c.Ctx.Input.IP()
-    
-    mutex.Lock()
-    lastTime, exists := ipRateLimiter[ip]
-    if exists && time.Since(lastTime) < 24*time.Hour {
-        mutex.Unlock()
-        c.Ctx.WriteString("Please only send at most one message per day.")
+    if ok, wait := c.allowRoute("logs_submit", "1/24h"); !ok {
+        c.Ctx.WriteString(fmt.Sprintf("Please only send one message per day. Try again in %s.", formatWait(wait)))
         return 
     }
-    ipRateLimiter[ip] = time.Now()
-    mutex.Unlock()
 
     if c.GetString("website_url") != "" {
         c.Redirect("/", 302)
@@ -146,6 +148,94 @@ func (c *PortfolioController) SubmitLog() {
     c.Redirect("/", 302)
 }
 
+// LogsJSON serves a page of the guestbook, newest first. Pass next_cursor
+// back as ?cursor= for the following page; ?q= searches names and messages.
+func (c *PortfolioController) LogsJSON() {
+    page, err := models.GetAccessLogPage(c.GetString("cursor"), c.GetString("q"), 0)
+    if err != nil {
+        c.Ctx.Output.SetStatus(400)
+        c.Data["json"] = map[string]interface{}{"error": err.Error()}
+        c.ServeJSON()
+        return
+    }
+
+    logs := make([]map[string]interface{}, len(page.Logs))
+    for i, l := range page.Logs {
+        logs[i] = logJSON(l)
+    }
+    c.Data["json"] = map[string]interface{}{
+        "logs":        logs,
+        "next_cursor": page.NextCursor,
+    }
+    c.ServeJSON()
+}
+
+// LogStream pushes newly approved guestbook entries as "log" events. Each
+// event id is the entry id, so a reconnecting browser's Last-Event-ID
+// replays whatever it missed.
+func (c *PortfolioController) LogStream() {
+    if models.LogEvents.Subscribers() >= web.AppConfig.DefaultInt("logs_stream_max_clients", 200) {
+        c.Ctx.Output.SetStatus(503)
+        c.Ctx.Output.Header("Retry-After", "60")
+        c.Data["json"] = map[string]interface{}{"error": "Too many listeners, try again later"}
+        c.ServeJSON()
+        return
+    }
+
+    logs, unsubscribe := models.LogEvents.Subscribe()
+    defer unsubscribe()
+
+    c.EnableRender = false
+    w := c.Ctx.ResponseWriter
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("X-Accel-Buffering", "no")
+    w.WriteHeader(200)
+
+    send := func(l models.AccessLog) {
+        payload, _ := json.Marshal(logJSON(l))
+        fmt.Fprintf(w, "id: %d\nevent: log\ndata: %s\n\n", l.Id, payload)
+    }
+
+    if lastId, err := strconv.Atoi(c.Ctx.Input.Header("Last-Event-ID")); err == nil {
+        for _, l := range models.GetAccessLogsAfter(lastId, 50) {
+            send(l)
+        }
+    }
+    w.Flush()
+
+    // Comments keep idle connections open through proxies
+    heartbeat := time.NewTicker(30 * time.Second)
+    defer heartbeat.Stop()
+
+    for {
+        select {
+        case l := <-logs:
+            send(l)
+            w.Flush()
+        case <-heartbeat.C:
+            fmt.Fprint(w, ": ping\n\n")
+            w.Flush()
+        case <-c.Ctx.Request.Context().Done():
+            return
+        }
+    }
+}
+
+// logJSON is the public view of a guestbook entry; moderation details and
+// the client fingerprint stay server-side.
+func logJSON(l models.AccessLog) map[string]interface{} {
+    return map[string]interface{}{
+        "id":        l.Id,
+        "signature": l.Signature,
+        "pid":       l.ProcessID,
+        "name":      l.Name,
+        "message":   l.Message,
+        "created":   l.Created,
+        "timestamp": l.Created.Format("01/02 15:04"),
+    }
+}
+
 // Sub page declarations
 func (c *PortfolioController) About() {
     c.Data["Title"] = "User Log"
@@ -209,28 +299,46 @@ func (c *PortfolioController) Challenge() {
     c.Data["LinkedinLink"] = "https://www.linkedin.com/in/jake-morgan-/"
 
     c.Data["Challenges"] = models.GetChallenges()
-    
+    c.Data["Stats"] = models.GetChallengeStats()
+    c.Data["Handle"] = c.handle()
+
+    flash := web.ReadFromRequest(&c.Controller)
+    c.Data["HandleError"] = flash.Data["error"]
+
     c.Layout = "layout.html"
     c.TplName = "challenges.html"
 }
 
-// RunCode sends the user's submission to the Piston Execution Engine
+// runRequest is the /challenges/run payload. UserCode carries the code, SQL
+// query or predicted output; Choice is only used by MCQ challenges.
+type runRequest struct {
+    ChallengeID int    `json:"challenge_id"`
+    UserCode    string `json:"user_code"`
+    Choice      *int   `json:"choice"`
+}
+
+// RunCode validates a submission and queues it for the configured execution
+// backend. Progress is read back through RunStatus or RunEvents. Non-code
+// challenges are graded immediately instead.
 func (c *PortfolioController) RunCode() {
     // 1. Parse Payload
-    var req struct {
-        ChallengeID int    `json:"challenge_id"`
-        UserCode    string `json:"user_code"`
-    }
-    
+    var req runRequest
+
     if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err != nil {
         c.Data["json"] = map[string]interface{}{"passed": false, "output": "System Error: Bad JSON"}
         c.ServeJSON()
         return
     }
 
+    if ok, wait := runLimiter.Allow(models.HashIP(c.Ctx.Input.IP())); !ok {
+        c.Ctx.Output.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+        c.rejectRun(fmt.Sprintf("Rate limit exceeded. Try again in %d seconds.", int(wait.Seconds())+1))
+        return
+    }
+
     // 2. Fetch Challenge & Test Cases
     challenge, err := models.GetChallengeById(req.ChallengeID) 
-    if err != nil {
+    if err != nil || !challenge.Published {
         c.Data["json"] = map[string]interface{}{
             "passed": false, 
             "output": "System Error: Challenge ID not found in database.",
@@ -239,6 +347,11 @@ func (c *PortfolioController) RunCode() {
         return
     }
 
+    if !challenge.IsCode() {
+        c.gradeAnswer(challenge, req)
+        return
+    }
+
     testCases := models.GetTestCases(req.ChallengeID)
     if len(testCases) == 0 {
         c.Data["json"] = map[string]interface{}{"passed": false, "output": "System Error: No test cases found in DB."}
@@ -246,90 +359,405 @@ func (c *PortfolioController) RunCode() {
         return
     }
 
-    // 3. Prepare Execution
-    version := "3.10.0" 
-    allPassed := true
-    var outputLog strings.Builder
-
     // Default to 'solve' if database field is empty
     funcName := challenge.FunctionName
     if funcName == "" {
         funcName = "solve"
     }
 
-    // 4. Execution Loop
-    for i, tc := range testCases {
-        
-        // --- RATE LIMIT FIX ---
-        if i > 0 {
-            time.Sleep(250 * time.Millisecond)
+    lang, err := models.LookupLanguage(challenge.Language)
+    if err != nil {
+        c.Data["json"] = map[string]interface{}{"passed": false, "output": "System Error: " + err.Error()}
+        c.ServeJSON()
+        return
+    }
+
+    if err := checkSubmission(challenge.Language, req.UserCode); err != nil {
+        c.rejectRun(err.Error())
+        return
+    }
+
+    // 3. Build a single harness run covering every test case
+    run, err := models.NewHarnessRun(lang, req.UserCode, funcName, testCases)
+    if err != nil {
+        c.Data["json"] = map[string]interface{}{"passed": false, "output": "System Error: " + err.Error()}
+        c.ServeJSON()
+        return
+    }
+
+    // 4. Hand off to the worker pool
+    job := models.NewRunJob(challenge, testCases, run, req.UserCode, c.Ctx.Input.IP(), c.Ctx.Input.UserAgent())
+    job.HintsUsed = c.hintsUsed(challenge.Id)
+    job.HintPenalty = models.HintPenalty(challenge.Id, job.HintsUsed)
+    job.Handle = c.handle()
+    if err := runQueue.Submit(job); err != nil {
+        c.rejectRun("Execution queue is full, try again shortly.")
+        return
+    }
+
+    c.Data["json"] = map[string]interface{}{
+        "job_id":     job.ID,
+        "status_url": "/challenges/run/" + job.ID,
+        "events_url": "/challenges/run/" + job.ID + "/events",
+    }
+    c.ServeJSON()
+}
+
+// gradeAnswer grades MCQ, SQL and PREDICT challenges in-process and replies
+// with the final result straight away; nothing is queued.
+func (c *PortfolioController) gradeAnswer(challenge models.Challenge, req runRequest) {
+    started := time.Now()
+
+    if err := checkSize(req.UserCode); err != nil {
+        c.rejectRun(err.Error())
+        return
+    }
+
+    var report models.GradeReport
+    var err error
+    answer := req.UserCode
+
+    switch strings.ToUpper(challenge.Type) {
+    case models.TypeMCQ:
+        if req.Choice == nil {
+            err = errors.New("no choice selected")
+            break
+        }
+        report, err = models.GradeChoice(challenge, *req.Choice)
+        if err == nil {
+            answer = challenge.ChoiceList()[*req.Choice]
         }
+    case models.TypeSQL:
+        report, err = models.GradeSQL(challenge, req.UserCode)
+    case models.TypePredict:
+        report = models.GradePrediction(challenge, req.UserCode)
+    default:
+        err = fmt.Errorf("unsupported challenge type %q", challenge.Type)
+    }
+
+    if err != nil {
+        c.Data["json"] = map[string]interface{}{"passed": false, "output": "System Error: " + err.Error()}
+        c.ServeJSON()
+        return
+    }
+
+    used := c.hintsUsed(challenge.Id)
+    report.ApplyHints(used, models.HintPenalty(challenge.Id, used))
+
+    result := map[string]interface{}{
+        "passed": report.Passed,
+        "score":  report.Score,
+        "output": report.Output,
+    }
+
+    submission := models.NewSubmission(&challenge, answer, report, models.HashIP(c.Ctx.Input.IP()), c.Ctx.Input.UserAgent(), started)
+    submission.Handle = c.handle()
+    if err := models.AddSubmission(&submission); err != nil {
+        fmt.Println("Submission not saved:", err)
+    } else {
+        result["submission_id"] = submission.Id
+        result["permalink"] = fmt.Sprintf("/challenges/submissions/%d", submission.Id)
+    }
+
+    c.Data["json"] = result
+    c.ServeJSON()
+}
+
+// --- Hints ---
+
+// publishedChallenge loads a challenge visitors may see. Missing and
+// unpublished challenges are answered with a 404 and ok is false.
+func (c *PortfolioController) publishedChallenge(id int) (challenge models.Challenge, ok bool) {
+    challenge, err := models.GetChallengeById(id)
+    if err != nil || !challenge.Published {
+        c.Ctx.Output.SetStatus(404)
+        c.Data["json"] = map[string]interface{}{"error": "Challenge not found"}
+        c.ServeJSON()
+        return challenge, false
+    }
+    return challenge, true
+}
+
+// hintsUsed returns how many hints this session has revealed for a challenge
+func (c *PortfolioController) hintsUsed(challengeId int) int {
+    used, _ := c.GetSession("hints_used").(map[int]int)
+    return used[challengeId]
+}
+
+func (c *PortfolioController) setHintsUsed(challengeId, n int) {
+    used, _ := c.GetSession("hints_used").(map[int]int)
+    if used == nil {
+        used = make(map[int]int)
+    }
+    used[challengeId] = n
+    c.SetSession("hints_used", used)
+}
+
+// Hints lists the hints this session has already revealed for a challenge
+func (c *PortfolioController) Hints() {
+    id, _ := strconv.Atoi(c.Ctx.Input.Param(":id"))
+    if _, ok := c.publishedChallenge(id); !ok {
+        return
+    }
+    c.serveHints(id, models.GetHints(id), c.hintsUsed(id))
+}
+
+// RevealHint reveals the challenge's next hint, if any, for this session
+func (c *PortfolioController) RevealHint() {
+    id, _ := strconv.Atoi(c.Ctx.Input.Param(":id"))
+    if _, ok := c.publishedChallenge(id); !ok {
+        return
+    }
+    hints := models.GetHints(id)
+
+    used := c.hintsUsed(id)
+    if used < len(hints) {
+        used++
+        c.setHintsUsed(id, used)
+    }
+    c.serveHints(id, hints, used)
+}
+
+func (c *PortfolioController) serveHints(challengeId int, hints []models.Hint, used int) {
+    revealed := []map[string]interface{}{}
+    penalty := 0.0
+    for _, h := range hints[:used] {
+        penalty += h.Penalty
+        revealed = append(revealed, map[string]interface{}{"position": h.Position, "text": h.Text, "penalty": h.Penalty})
+    }
+
+    next := 0.0
+    if used < len(hints) {
+        next = hints[used].Penalty
+    }
 
-        fullCode := fmt.Sprintf("%s\n\nprint(%s(%s))", req.UserCode, funcName, tc.InputArgs)
+    c.Data["json"] = map[string]interface{}{
+        "challenge_id": challengeId,
+        "total":        len(hints),
+        "revealed":     revealed,
+        "penalty":      penalty,
+        "next_penalty": next,
+    }
+    c.ServeJSON()
+}
+
+// --- Drafts ---
+
+// draftCookie holds a random key that owns this browser's drafts. It is
+// kept apart from the session so drafts survive restarts and reach every
+// instance, and lives as long as drafts do.
+const draftCookie = "portfolio_draft"
+
+var draftKeyRe = regexp.MustCompile(`^[0-9a-f]{32}$`)
 
-        pistonReq := models.PistonRequest{
-            Language: "python",
-            Version:  version,
-            Files: []models.PistonFile{
-                {Name: "main.py", Content: fullCode},
-            },
+// draftKey returns this browser's draft key. With create, a missing key is
+// issued, and an existing one has its cookie renewed. It returns "" when
+// there is no key to use.
+func (c *PortfolioController) draftKey(create bool) string {
+    key := c.Ctx.GetCookie(draftCookie)
+    if !draftKeyRe.MatchString(key) {
+        if !create {
+            return ""
         }
+        b := make([]byte, 16)
+        if _, err := rand.Read(b); err != nil {
+            fmt.Println("Draft key not issued:", err)
+            return ""
+        }
+        key = hex.EncodeToString(b)
+    }
+    if create {
+        maxAge := int(models.DraftRetention().Seconds())
+        c.Ctx.SetCookie(draftCookie, key, maxAge, "/", "", c.Ctx.Input.IsSecure(), true, "Lax")
+    }
+    return key
+}
 
-        reqBody, _ := json.Marshal(pistonReq)
-        
-        client := &http.Client{Timeout: 10 * time.Second}
-        r, _ := http.NewRequest("POST", "https://emkc.org/api/v2/piston/execute", bytes.NewBuffer(reqBody))
-        r.Header.Set("Content-Type", "application/json")
+// Draft returns the saved editor content for a challenge, if any
+func (c *PortfolioController) Draft() {
+    id, _ := strconv.Atoi(c.Ctx.Input.Param(":id"))
 
-        resp, err := client.Do(r)
-        
-        if err != nil {
-            c.Data["json"] = map[string]interface{}{"error": "Execution Engine Offline"}
-            c.ServeJSON()
+    result := map[string]interface{}{"challenge_id": id, "code": nil}
+    if d := models.GetDraft(c.draftKey(false), id); d != nil {
+        result["code"] = d.Code
+        result["updated"] = d.Updated
+    }
+    c.Data["json"] = result
+    c.ServeJSON()
+}
+
+// SaveDraft stores the editor content for a challenge, replacing any earlier draft
+func (c *PortfolioController) SaveDraft() {
+    id, _ := strconv.Atoi(c.Ctx.Input.Param(":id"))
+
+    var req struct {
+        Code string `json:"code"`
+    }
+    if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err != nil {
+        c.Ctx.Output.SetStatus(400)
+        c.Data["json"] = map[string]interface{}{"error": "Bad JSON"}
+        c.ServeJSON()
+        return
+    }
+    if err := checkSize(req.Code); err != nil {
+        c.Ctx.Output.SetStatus(413)
+        c.Data["json"] = map[string]interface{}{"error": err.Error()}
+        c.ServeJSON()
+        return
+    }
+    if _, ok := c.publishedChallenge(id); !ok {
+        return
+    }
+    if ok, wait := c.allowRoute("draft_save", "1200/1h"); !ok {
+        c.Data["json"] = map[string]interface{}{"error": fmt.Sprintf("Too many saves. Try again in %s.", formatWait(wait))}
+        c.ServeJSON()
+        return
+    }
+
+    key := c.draftKey(true)
+    if key == "" {
+        c.Ctx.Output.SetStatus(500)
+        c.Data["json"] = map[string]interface{}{"error": "Draft not saved"}
+        c.ServeJSON()
+        return
+    }
+
+    d, err := models.SaveDraft(key, models.HashIP(c.Ctx.Input.IP()), id, req.Code)
+    if errors.Is(err, models.ErrTooManyDrafts) {
+        c.Ctx.Output.SetStatus(429)
+        c.Data["json"] = map[string]interface{}{"error": "Too many drafts from this address."}
+        c.ServeJSON()
+        return
+    }
+    if err != nil {
+        fmt.Println("Draft not saved:", err)
+        c.Ctx.Output.SetStatus(500)
+        c.Data["json"] = map[string]interface{}{"error": "Draft not saved"}
+        c.ServeJSON()
+        return
+    }
+    c.Data["json"] = map[string]interface{}{"challenge_id": id, "updated": d.Updated}
+    c.ServeJSON()
+}
+
+// DeleteDraft discards the saved draft, used when resetting to the starter code
+func (c *PortfolioController) DeleteDraft() {
+    id, _ := strconv.Atoi(c.Ctx.Input.Param(":id"))
+    if err := models.DeleteDraft(c.draftKey(false), id); err != nil {
+        fmt.Println("Draft not deleted:", err)
+    }
+    c.Data["json"] = map[string]interface{}{"challenge_id": id}
+    c.ServeJSON()
+}
+
+// rejectRun answers a refused /challenges/run request with a 429
+func (c *PortfolioController) rejectRun(reason string) {
+    c.Ctx.Output.SetStatus(429)
+    c.Data["json"] = map[string]interface{}{"passed": false, "error": reason}
+    c.ServeJSON()
+}
+
+// RunStatus reports a queued run's progress for polling clients
+func (c *PortfolioController) RunStatus() {
+    job, ok := runQueue.Get(c.Ctx.Input.Param(":id"))
+    if !ok {
+        c.Ctx.Output.SetStatus(404)
+        c.Data["json"] = map[string]interface{}{"error": "Run not found or expired"}
+        c.ServeJSON()
+        return
+    }
+
+    c.Data["json"] = job.Snapshot()
+    c.ServeJSON()
+}
+
+// RunEvents streams a queued run's progress as Server-Sent Events
+func (c *PortfolioController) RunEvents() {
+    job, ok := runQueue.Get(c.Ctx.Input.Param(":id"))
+    if !ok {
+        c.Ctx.Output.SetStatus(404)
+        c.Data["json"] = map[string]interface{}{"error": "Run not found or expired"}
+        c.ServeJSON()
+        return
+    }
+
+    events, unsubscribe := job.Subscribe()
+    defer unsubscribe()
+
+    c.EnableRender = false
+    w := c.Ctx.ResponseWriter
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("X-Accel-Buffering", "no")
+    w.WriteHeader(200)
+
+    for {
+        select {
+        case ev, open := <-events:
+            if !open {
+                return
+            }
+            payload, _ := json.Marshal(ev)
+            fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, payload)
+            w.Flush()
+        case <-c.Ctx.Request.Context().Done():
             return
         }
-        defer resp.Body.Close()
+    }
+}
 
-        var bodyBytes []byte
-        if resp.Body != nil {
-            bodyBytes, _ = io.ReadAll(resp.Body)
-        }
+// Submission renders the shareable permalink page for a past run
+func (c *PortfolioController) Submission() {
+    id, _ := c.GetInt(":id")
+    submission, err := models.GetSubmissionById(id)
+    if err != nil {
+        c.Abort("404")
+    }
 
-        // Check for API errors
-        if resp.StatusCode != 200 {
-            outputLog.WriteString(fmt.Sprintf("API Error (Case %d): %s\n", i+1, string(bodyBytes)))
-            allPassed = false
-            break 
-        }
+    c.Data["Title"] = "Submission #" + strconv.Itoa(submission.Id)
+    c.Data["Name"] = "Jake Morgan"
+    c.Data["Page"] = "challenges"
+    c.Data["Email"] = "jmorgan3142001@gmail.com"
+    c.Data["GithubLink"] = "https://github.com/jmorgan3142001"
+    c.Data["LinkedinLink"] = "https://www.linkedin.com/in/jake-morgan-/"
 
-        var pistonResp models.PistonResponse
-        if err := json.Unmarshal(bodyBytes, &pistonResp); err != nil {
-            allPassed = false
-            outputLog.WriteString("Error parsing execution response.\n")
-            continue
-        }
+    c.Data["Submission"] = submission
 
-        actualOutput := strings.TrimSpace(pistonResp.Run.Stdout)
-        expected := strings.TrimSpace(tc.ExpectedOutput)
+    c.Layout = "layout.html"
+    c.TplName = "submission.html"
+}
 
-        if pistonResp.Run.Stderr != "" {
-            allPassed = false
-            cleanErr := strings.Replace(pistonResp.Run.Stderr, "/piston/jobs/", "", -1)
-            outputLog.WriteString(fmt.Sprintf("ERROR on input (%s):\n%s\n", tc.InputArgs, cleanErr))
-            break 
-        }
+// SubmissionJSON returns a past run with hidden case details redacted
+func (c *PortfolioController) SubmissionJSON() {
+    id, _ := c.GetInt(":id")
+    submission, err := models.GetSubmissionById(id)
+    if err != nil {
+        c.Ctx.Output.SetStatus(404)
+        c.Data["json"] = map[string]interface{}{"error": "Submission not found"}
+        c.ServeJSON()
+        return
+    }
 
-        if actualOutput == expected {
-            outputLog.WriteString(fmt.Sprintf("✓ PASS: Input(%s) -> Output(%s)\n", tc.InputArgs, actualOutput))
-        } else {
-            allPassed = false
-            outputLog.WriteString(fmt.Sprintf("✗ FAIL: Input(%s)\n  Expected: %s\n  Got:      %s\n", tc.InputArgs, expected, actualOutput))
-        }
+    var cases []models.CaseOutcome
+    json.Unmarshal([]byte(submission.Results), &cases)
+    for i := range cases {
+        cases[i] = cases[i].Public()
     }
 
     c.Data["json"] = map[string]interface{}{
-        "passed": allPassed,
-        "output": outputLog.String(),
+        "id":              submission.Id,
+        "challenge_id":    submission.Challenge.Id,
+        "challenge_title": submission.Challenge.Title,
+        "challenge_slug":  submission.Challenge.Slug,
+        "version":         submission.Version,
+        "current_version": submission.Challenge.Version,
+        "code":            submission.Code,
+        "passed":          submission.Passed,
+        "score":           submission.Score,
+        "duration_ms":     submission.Duration,
+        "output":          submission.Output,
+        "results":         cases,
+        "created":         submission.Created,
     }
     c.ServeJSON()
 }
\ No newline at end of file
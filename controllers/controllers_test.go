@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"portfolio-site/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+// eventsServer serves RunEvents the way routers/router.go mounts it.
+func eventsServer(t *testing.T) *httptest.Server {
+    t.Helper()
+    handler := web.NewControllerRegister()
+    handler.Add("/challenges/run/:id/events", &PortfolioController{}, web.WithRouterMethods(&PortfolioController{}, "get:RunEvents"))
+    server := httptest.NewServer(handler)
+    t.Cleanup(server.Close)
+    return server
+}
+
+func TestRunEvents(t *testing.T) {
+    os.Setenv("IP_HASH_SALT", "test-salt")
+    defer func(q *models.JobQueue) { runQueue = q }(runQueue)
+    // No workers: the job stays queued, so the stream stays open
+    runQueue = models.NewJobQueue(models.NewFakeExecutor(nil), 0, 1)
+
+    lang, _ := models.LookupLanguage("python")
+    cases := []models.TestCase{{InputArgs: "1", ExpectedOutput: "1"}}
+    run, err := models.NewHarnessRun(lang, "def solve(x):\n    return x\n", "solve", cases)
+    if err != nil {
+        t.Fatal(err)
+    }
+    job := models.NewRunJob(models.Challenge{Language: "python"}, cases, run, "", "127.0.0.1", "go-test")
+    if err := runQueue.Submit(job); err != nil {
+        t.Fatal(err)
+    }
+
+    server := eventsServer(t)
+
+    t.Run("unknown run", func(t *testing.T) {
+        resp, err := http.Get(server.URL + "/challenges/run/nope/events")
+        if err != nil {
+            t.Fatal(err)
+        }
+        resp.Body.Close()
+        if resp.StatusCode != 404 {
+            t.Errorf("status = %d, want 404", resp.StatusCode)
+        }
+    })
+
+    t.Run("replays events and stops when the client leaves", func(t *testing.T) {
+        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancel()
+        req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/challenges/run/"+job.ID+"/events", nil)
+        resp, err := http.DefaultClient.Do(req)
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer resp.Body.Close()
+
+        if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+            t.Errorf("Content-Type = %q", ct)
+        }
+
+        // The queued event was published before we connected
+        reader := bufio.NewReader(resp.Body)
+        var lines []string
+        for len(lines) < 2 {
+            line, err := reader.ReadString('\n')
+            if err != nil {
+                t.Fatalf("stream ended early: %v (read %q)", err, lines)
+            }
+            lines = append(lines, strings.TrimRight(line, "\n"))
+        }
+        if lines[0] != "event: status" {
+            t.Errorf("first line = %q, want the event name", lines[0])
+        }
+        var ev models.JobEvent
+        if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &ev); err != nil || ev.Status != models.JobQueued {
+            t.Errorf("data line = %q, want the queued status", lines[1])
+        }
+        cancel()
+    })
+}
+
+func TestLogsJSONRejectsBadCursor(t *testing.T) {
+    handler := web.NewControllerRegister()
+    handler.Add("/api/logs", &PortfolioController{}, web.WithRouterMethods(&PortfolioController{}, "get:LogsJSON"))
+    server := httptest.NewServer(handler)
+    defer server.Close()
+
+    resp, err := http.Get(server.URL + "/api/logs?cursor=abc")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    var body map[string]string
+    json.NewDecoder(resp.Body).Decode(&body)
+    if resp.StatusCode != 400 || body["error"] != "invalid cursor" {
+        t.Errorf("status = %d, body = %v; want 400 invalid cursor", resp.StatusCode, body)
+    }
+}
+
+func TestLogStream(t *testing.T) {
+    handler := web.NewControllerRegister()
+    handler.Add("/logs/stream", &PortfolioController{}, web.WithRouterMethods(&PortfolioController{}, "get:LogStream"))
+    server := httptest.NewServer(handler)
+    defer server.Close()
+
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/logs/stream", nil)
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+        t.Errorf("Content-Type = %q", ct)
+    }
+
+    t.Run("delivers published entries", func(t *testing.T) {
+        // The handler subscribes before writing headers
+        models.LogEvents.Publish(models.AccessLog{Id: 42, Name: "ada", Message: "hi", Moderation: `{"secret":1}`})
+        reader := bufio.NewReader(resp.Body)
+        var lines []string
+        for len(lines) < 3 {
+            line, err := reader.ReadString('\n')
+            if err != nil {
+                t.Fatalf("stream ended early: %v (read %q)", err, lines)
+            }
+            lines = append(lines, strings.TrimRight(line, "\n"))
+        }
+        if lines[0] != "id: 42" || lines[1] != "event: log" {
+            t.Errorf("event header = %q", lines[:2])
+        }
+        if !strings.Contains(lines[2], `"name":"ada"`) || strings.Contains(lines[2], "secret") {
+            t.Errorf("data line = %q, want the public view only", lines[2])
+        }
+    })
+
+    t.Run("turns listeners away over the cap", func(t *testing.T) {
+        defer web.AppConfig.Set("logs_stream_max_clients", "")
+        web.AppConfig.Set("logs_stream_max_clients", "1")
+        resp, err := http.Get(server.URL + "/logs/stream")
+        if err != nil {
+            t.Fatal(err)
+        }
+        resp.Body.Close()
+        if resp.StatusCode != 503 || resp.Header.Get("Retry-After") == "" {
+            t.Errorf("status = %d, Retry-After %q; want 503 with a retry hint", resp.StatusCode, resp.Header.Get("Retry-After"))
+        }
+    })
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"portfolio-site/models"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+// --- Handles ---
+
+// handleCookie carries the token from models.ClaimHandle, so a handle
+// outlives the session it was claimed in.
+const handleCookie = "portfolio_handle"
+
+// handle returns the leaderboard handle claimed in this browser, if any,
+// re-attaching it to the session from the handle cookie when needed
+func (c *PortfolioController) handle() *models.Handle {
+    if id, _ := c.GetSession("handle_id").(int); id != 0 {
+        if h, err := models.GetHandleById(id); err == nil {
+            return h
+        }
+    }
+
+    token := c.Ctx.GetCookie(handleCookie)
+    if token == "" {
+        return nil
+    }
+    h, err := models.HandleFromToken(token)
+    if err != nil {
+        return nil
+    }
+    c.SetSession("handle_id", h.Id)
+    return h
+}
+
+// ClaimHandle picks a leaderboard handle for this session. Solves are only
+// credited from submissions made after it is claimed.
+func (c *PortfolioController) ClaimHandle() {
+    flash := web.NewFlash()
+
+    if c.handle() != nil {
+        flash.Error("You already have a handle.")
+        flash.Store(&c.Controller)
+        c.Redirect("/challenges", 302)
+        return
+    }
+
+    if ok, wait := c.allowRoute("handle_claim", "5/1h"); !ok {
+        flash.Error("Too many attempts. Try again in %s.", formatWait(wait))
+        flash.Store(&c.Controller)
+        c.Redirect("/challenges", 302)
+        return
+    }
+
+    h, token, err := models.ClaimHandle(c.GetString("handle"))
+    if err != nil {
+        flash.Error("%s", err.Error())
+        flash.Store(&c.Controller)
+        c.Redirect("/challenges", 302)
+        return
+    }
+
+    maxAge := web.AppConfig.DefaultInt("handle_cookie_days", 365) * 24 * 60 * 60
+    c.Ctx.SetCookie(handleCookie, token, maxAge, "/", "", c.Ctx.Input.IsSecure(), true, "Lax")
+    c.SetSession("handle_id", h.Id)
+    c.Redirect("/challenges", 302)
+}
+
+// --- Leaderboard & Certificates ---
+
+func (c *PortfolioController) Leaderboard() {
+    c.Data["Title"] = "Leaderboard"
+    c.Data["Name"] = "Jake Morgan"
+    c.Data["Page"] = "challenges"
+    c.Data["Email"] = "jmorgan3142001@gmail.com"
+    c.Data["GithubLink"] = "https://github.com/jmorgan3142001"
+    c.Data["LinkedinLink"] = "https://www.linkedin.com/in/jake-morgan-/"
+
+    c.Data["Entries"] = models.GetLeaderboard(web.AppConfig.DefaultInt("leaderboard_size", 50))
+    c.Data["Handle"] = c.handle()
+
+    c.Layout = "layout.html"
+    c.TplName = "leaderboard.html"
+}
+
+// Certificate shows a handle's solved challenges with a signature over them.
+// Given ?sig=, it instead reports which of the solves that signature covers.
+func (c *PortfolioController) Certificate() {
+    h, err := models.GetHandleByName(c.Ctx.Input.Param(":handle"))
+    if err != nil {
+        c.Abort("404")
+        return
+    }
+
+    solves := models.GetSolves(h.Id)
+    secret := models.CertificateSecret()
+    sig := c.GetString("sig")
+
+    if sig != "" {
+        n := models.VerifyCertificate(secret, h.Name, solves, sig)
+        c.Data["Checked"] = true
+        c.Data["Verified"] = n > 0
+        solves = solves[:n]
+    }
+
+    c.Data["Title"] = "Certificate // " + h.Name
+    c.Data["Name"] = "Jake Morgan"
+    c.Data["Page"] = "challenges"
+    c.Data["Email"] = "jmorgan3142001@gmail.com"
+    c.Data["GithubLink"] = "https://github.com/jmorgan3142001"
+    c.Data["LinkedinLink"] = "https://www.linkedin.com/in/jake-morgan-/"
+
+    c.Data["Owner"] = h
+    c.Data["Solves"] = solves
+    if len(solves) > 0 {
+        signature := models.SignCertificate(secret, h.Name, solves)
+        c.Data["Signature"] = signature
+        c.Data["Permalink"] = fmt.Sprintf("/challenges/certificates/%s?sig=%s", url.PathEscape(h.Name), signature)
+    }
+
+    c.Layout = "layout.html"
+    c.TplName = "certificate.html"
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"portfolio-site/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+// --- Abuse Protection for /challenges/run ---
+
+var (
+    runLimiter = newTokenBuckets(
+        web.AppConfig.DefaultFloat("run_rate_per_minute", 6)/60,
+        web.AppConfig.DefaultFloat("run_burst", 3),
+    )
+    maxCodeBytes = web.AppConfig.DefaultInt("run_max_code_kb", 16) * 1024
+    runDenylist  = loadDenylists()
+)
+
+// Node builtins that reach outside the sandbox, and patterns shared by
+// JavaScript and TypeScript.
+const nodeModules = `(child_process|fs|net|http|https|dgram|cluster|worker_threads|vm)`
+
+// Go packages that reach outside the sandbox. Only import paths are matched,
+// so the same words in an ordinary string literal are fine.
+const goPackages = `"(os|os/exec|net|net/http|syscall|unsafe|plugin|runtime/debug)"`
+
+var jsDenylist = []string{
+    `require\s*\(\s*['"]` + nodeModules + `['"]`,
+    `\bprocess\s*\.\s*(binding|kill|exit|env)\b`,
+    `(^|[^.\w])(eval|Function)\s*\(`,
+}
+
+// Patterns rejected before a submission reaches the executor, per
+// Challenge.Language. Overridden with a ;-separated `run_denylist_<lang>`.
+var defaultDenylists = map[string][]string{
+    "python": {
+        `(?m)^\s*(import|from)\s+(os|subprocess|socket|ctypes|multiprocessing|shutil|signal|resource|pty|importlib)\b`,
+        `\b(__import__|__builtins__|__subclasses__|importlib)\b`,
+        `(^|[^.\w])(eval|exec|compile|open)\s*\(`,
+    },
+    "javascript": jsDenylist,
+    "typescript": append([]string{
+        `\bfrom\s+['"]` + nodeModules + `['"]`,
+    }, jsDenylist...),
+    "go": {
+        `(?m)^\s*import\s+([\w.]+\s+)?` + goPackages, // import "os", import x "os"
+        `(?m)^\s*import\s*\([^)]*?` + goPackages, // import ( ... "os" ... )
+    },
+}
+
+// loadDenylists compiles the denylists. A pattern that doesn't compile is
+// skipped and logged rather than taking the site down at startup.
+func loadDenylists() map[string][]*regexp.Regexp {
+    lists := make(map[string][]*regexp.Regexp)
+    for lang, defaults := range defaultDenylists {
+        for _, p := range web.AppConfig.DefaultStrings("run_denylist_"+lang, defaults) {
+            re, err := regexp.Compile(strings.TrimSpace(p))
+            if err != nil {
+                fmt.Printf("Denylist pattern %q for %s skipped: %v\n", p, lang, err)
+                continue
+            }
+            lists[lang] = append(lists[lang], re)
+        }
+    }
+    return lists
+}
+
+// checkSubmission enforces the size limit and denylist on user code. The
+// returned message is shown to the user as-is.
+func checkSubmission(language, code string) error {
+    if err := checkSize(code); err != nil {
+        return err
+    }
+
+    if language == "" {
+        language = "python"
+    }
+    for _, re := range runDenylist[strings.ToLower(language)] {
+        if match := re.FindString(code); match != "" {
+            // A grouped import matches from its first line; name the culprit
+            match = strings.TrimSpace(match)
+            if i := strings.LastIndex(match, "\n"); i >= 0 {
+                match = strings.TrimSpace(match[i+1:])
+            }
+            return fmt.Errorf("Submission rejected: %q is not allowed in the sandbox.", match)
+        }
+    }
+    return nil
+}
+
+// checkSize applies only the size limit, for answers that are never executed.
+func checkSize(answer string) error {
+    if len(answer) > maxCodeBytes {
+        return fmt.Errorf("Submission too large: %d bytes (limit %d).", len(answer), maxCodeBytes)
+    }
+    return nil
+}
+
+// --- Per-Route Limits ---
+
+// routeLimiter backs the fixed-window limits on form posts; see
+// `ratelimit_backend` and the `ratelimit_<route>` keys in app.conf.
+var routeLimiter = models.NewRateLimiter()
+
+// allowRoute records a hit on route for this client, keyed by hashed IP.
+// Over the limit it sets Retry-After and returns the wait. If the backend
+// fails the request is let through, since a limiter outage shouldn't take
+// the site down with it.
+func (c *PortfolioController) allowRoute(route, def string) (bool, time.Duration) {
+    key := models.RateLimitKey(route, c.Ctx.Input.IP())
+    ok, wait, err := routeLimiter.Allow(key, models.RouteLimit(route, def))
+    if err != nil {
+        fmt.Println("Rate limiter error:", err)
+        return true, 0
+    }
+    if !ok {
+        c.Ctx.Output.SetStatus(429)
+        c.Ctx.Output.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+    }
+    return ok, wait
+}
+
+// formatWait renders a Retry-After wait for people, e.g. "3h20m" or "45s".
+func formatWait(wait time.Duration) string {
+    if wait >= time.Minute {
+        wait = wait.Round(time.Minute)
+        return strings.TrimSuffix(wait.String(), "0s")
+    }
+    return wait.Round(time.Second).String()
+}
+
+// --- Token Bucket ---
+
+// tokenBuckets is a per-key token bucket: each key may burst up to `burst`
+// requests, refilled at `rate` tokens per second. A rate of 0 or less
+// disables the limit.
+type tokenBuckets struct {
+    rate  float64
+    burst float64
+
+    mu      sync.Mutex
+    buckets map[string]*bucket
+}
+
+type bucket struct {
+    tokens float64
+    last   time.Time
+}
+
+func newTokenBuckets(rate, burst float64) *tokenBuckets {
+    if burst < 1 {
+        burst = 1
+    }
+    return &tokenBuckets{rate: rate, burst: burst, buckets: make(map[string]*bucket)}
+}
+
+// Allow takes a token for key. When none is left it reports how long until
+// the next one is available.
+func (t *tokenBuckets) Allow(key string) (bool, time.Duration) {
+    if t.rate <= 0 {
+        return true, 0
+    }
+
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    now := time.Now()
+    b, ok := t.buckets[key]
+    if !ok {
+        if len(t.buckets) > 10000 {
+            t.prune(now)
+        }
+        b = &bucket{tokens: t.burst, last: now}
+        t.buckets[key] = b
+    }
+
+    b.tokens = math.Min(t.burst, b.tokens+now.Sub(b.last).Seconds()*t.rate)
+    b.last = now
+
+    if b.tokens < 1 {
+        wait := time.Duration((1 - b.tokens) / t.rate * float64(time.Second))
+        return false, wait
+    }
+    b.tokens--
+    return true, 0
+}
+
+// prune drops buckets that have refilled completely; they behave exactly
+// like a fresh bucket, so forgetting them is free.
+func (t *tokenBuckets) prune(now time.Time) {
+    for key, b := range t.buckets {
+        if b.tokens+now.Sub(b.last).Seconds()*t.rate >= t.burst {
+            delete(t.buckets, key)
+        }
+    }
+}
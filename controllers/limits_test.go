@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckSubmission(t *testing.T) {
+    tests := []struct {
+        name     string
+        language string
+        code     string
+        want     string // Substring of the rejection; "" means accepted
+    }{
+        {"python import", "python", "import os\nos.system('ls')", `"import os"`},
+        {"python from import", "", "from subprocess import run", `"from subprocess"`},
+        {"python open", "python", "def solve():\n    return open('/etc/passwd').read()", "open("},
+        {"python method named open", "python", "def solve(d):\n    return d.open()", ""},
+        {"python os in a string", "python", "def solve():\n    return 'import os'", ""},
+        {"javascript require", "javascript", "const cp = require('child_process')", "child_process"},
+        {"typescript import", "typescript", "import { readFileSync } from 'fs'", `from 'fs'`},
+        {"go single import", "go", "package main\n\nimport \"os\"\n", `import \"os\"`},
+        {"go aliased import", "go", "package main\n\nimport x \"os/exec\"\n", `os/exec`},
+        {"go blank import", "go", "package main\n\nimport _ \"net/http\"\n", `net/http`},
+        {"go grouped import", "go", "package main\n\nimport (\n\t\"fmt\"\n\tsys \"syscall\"\n)\n", `sys \"syscall\"`},
+        {"go allowed imports", "go", "package main\n\nimport (\n\t\"fmt\"\n\t\"strings\"\n)\n", ""},
+        {"go package names in strings", "go", "package main\n\nimport \"strings\"\n\nfunc solve(s string) bool {\n\treturn strings.Contains(s, \"os\") || s == \"net\"\n}\n", ""},
+        {"too large", "python", strings.Repeat("x", maxCodeBytes+1), "Submission too large"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := checkSubmission(tt.language, tt.code)
+            if tt.want == "" {
+                if err != nil {
+                    t.Errorf("rejected: %v", err)
+                }
+                return
+            }
+            if err == nil || !strings.Contains(err.Error(), tt.want) {
+                t.Errorf("error = %v, want %q", err, tt.want)
+            }
+        })
+    }
+}
+
+func TestTokenBuckets(t *testing.T) {
+    tests := []struct {
+        rate, burst float64
+        hits        int
+        allow       int // How many back-to-back hits are allowed
+    }{
+        {rate: 0, burst: 1, hits: 50, allow: 50},    // Disabled
+        {rate: -1, burst: 1, hits: 50, allow: 50},   // Disabled
+        {rate: 0.001, burst: 0, hits: 3, allow: 1},  // Burst of at least one
+        {rate: 0.001, burst: 3, hits: 5, allow: 3},
+        {rate: 0.001, burst: 2.5, hits: 5, allow: 2},
+    }
+
+    for _, tt := range tests {
+        t.Run(fmt.Sprintf("rate %g burst %g", tt.rate, tt.burst), func(t *testing.T) {
+            buckets := newTokenBuckets(tt.rate, tt.burst)
+
+            allowed := 0
+            for i := 0; i < tt.hits; i++ {
+                ok, wait := buckets.Allow("k")
+                if ok {
+                    allowed++
+                    continue
+                }
+                // At 0.001/s a token takes 1000s to come back
+                if wait <= 0 || wait > 1000*time.Second {
+                    t.Errorf("wait = %v, want within one refill", wait)
+                }
+            }
+            if allowed != tt.allow {
+                t.Errorf("allowed %d hits, want %d", allowed, tt.allow)
+            }
+
+            if ok, _ := buckets.Allow("other"); !ok {
+                t.Error("a fresh key was limited")
+            }
+        })
+    }
+}
+
+func TestTokenBucketsRefill(t *testing.T) {
+    buckets := newTokenBuckets(100, 1) // One token every 10ms
+
+    if ok, _ := buckets.Allow("k"); !ok {
+        t.Fatal("first hit was limited")
+    }
+    if ok, _ := buckets.Allow("k"); ok {
+        t.Fatal("second hit was allowed with an empty bucket")
+    }
+    time.Sleep(30 * time.Millisecond)
+    if ok, _ := buckets.Allow("k"); !ok {
+        t.Error("bucket did not refill")
+    }
+}
+
+func TestTokenBucketsPrune(t *testing.T) {
+    buckets := newTokenBuckets(1000, 1)
+    buckets.Allow("spent")
+    buckets.Allow("also-spent")
+
+    time.Sleep(10 * time.Millisecond)
+    buckets.prune(time.Now())
+    if n := len(buckets.buckets); n != 0 {
+        t.Errorf("%d refilled buckets kept after prune", n)
+    }
+}
@@ -1,13 +1,31 @@
 package main
 
 import (
+	"flag"
+	"os"
+	"time"
+
+	"portfolio-site/models"
 	_ "portfolio-site/routers"
 
 	"github.com/beego/beego/v2/server/web"
 )
 
 func main() {
+	verify := flag.Bool("verify-challenges", false, "check every challenge's reference solution against its test cases, then exit")
+	flag.Parse()
+
+	models.InitDB()
+
+	if *verify {
+		if models.VerifyChallenges(models.NewExecutor()) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	models.StartDraftPruner(time.Hour)
+
 	web.SetStaticPath("/static", "static")
 	web.Run()
 }
-
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// addLogs inserts approved entries named log-1 ... log-n, plus one pending
+// entry that pages must never include.
+func addLogs(t *testing.T, n int) {
+    t.Helper()
+    clearTables(t, "access_log")
+    o := orm.NewOrm()
+    for i := 1; i <= n; i++ {
+        log := AccessLog{Name: fmt.Sprintf("log-%d", i), Message: "hello", Status: LogApproved}
+        if i%3 == 0 {
+            log.Message = "Greetings from Lisbon"
+        }
+        if _, err := o.Insert(&log); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if _, err := o.Insert(&AccessLog{Name: "held", Message: "hello lisbon", Status: LogPending}); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestGetAccessLogPage(t *testing.T) {
+    addLogs(t, 7)
+
+    // Walk every page; each is newest first and they don't overlap
+    var names []string
+    cursor := ""
+    for pages := 0; ; pages++ {
+        if pages > 4 {
+            t.Fatal("cursor never ran out")
+        }
+        page, err := GetAccessLogPage(cursor, "", 3)
+        if err != nil {
+            t.Fatal(err)
+        }
+        for _, l := range page.Logs {
+            names = append(names, l.Name)
+        }
+        if page.NextCursor == "" {
+            break
+        }
+        cursor = page.NextCursor
+    }
+    want := "[log-7 log-6 log-5 log-4 log-3 log-2 log-1]"
+    if got := fmt.Sprint(names); got != want {
+        t.Errorf("pages = %s, want %s", got, want)
+    }
+
+    // An exact fit has no further page
+    if page, _ := GetAccessLogPage("", "", 7); len(page.Logs) != 7 || page.NextCursor != "" {
+        t.Errorf("page of 7 = %d logs, cursor %q", len(page.Logs), page.NextCursor)
+    }
+
+    for _, bad := range []string{"abc", "0", "-4"} {
+        if _, err := GetAccessLogPage(bad, "", 3); err == nil {
+            t.Errorf("cursor %q accepted", bad)
+        }
+    }
+}
+
+func TestGetAccessLogPageSearch(t *testing.T) {
+    addLogs(t, 7)
+
+    page, err := GetAccessLogPage("", "  lisbon ", 1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(page.Logs) != 1 || page.Logs[0].Name != "log-6" || page.NextCursor == "" {
+        t.Fatalf("first match = %+v, cursor %q", page.Logs, page.NextCursor)
+    }
+    page, _ = GetAccessLogPage(page.NextCursor, "lisbon", 1)
+    if len(page.Logs) != 1 || page.Logs[0].Name != "log-3" || page.NextCursor != "" {
+        t.Errorf("second match = %+v, cursor %q", page.Logs, page.NextCursor)
+    }
+
+    // Names are searched too
+    if page, _ := GetAccessLogPage("", "LOG-5", 10); len(page.Logs) != 1 {
+        t.Errorf("name search matched %d logs", len(page.Logs))
+    }
+}
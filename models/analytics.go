@@ -0,0 +1,205 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// ===================================================================================
+// CHALLENGE ANALYTICS
+// ===================================================================================
+
+// ChallengeStats summarises every stored submission for one challenge. A
+// "solver" is one visitor, identified by Submission.IpHash.
+type ChallengeStats struct {
+    ChallengeId          int
+    Attempts             int       // Submissions of any outcome
+    Solvers              int       // Distinct visitors who submitted
+    Passed               int       // Visitors who passed at least once
+    FirstTryPassRate     float64   // Share of visitors whose first submission passed, 0-1
+    MedianAttemptsToPass float64   // Over visitors who passed; 0 when nobody has
+    TopFailingCaseId     int       // Test case failed by the most submissions
+    TopFailingCount      int
+    TopFailingCase       *TestCase // Loaded by GetChallengeStats
+    Calibration          string    // Warning when Difficulty doesn't match the pass rate
+}
+
+// FirstTryPercent is FirstTryPassRate for display.
+func (s ChallengeStats) FirstTryPercent() string {
+    return fmt.Sprintf("%.0f%%", s.FirstTryPassRate*100)
+}
+
+// First-try pass rates at or above these are considered that difficulty.
+const (
+    easyPassRate   = 0.6
+    mediumPassRate = 0.3
+)
+
+// ObservedDifficulty maps a first-try pass rate onto the Difficulty labels.
+func ObservedDifficulty(rate float64) string {
+    switch {
+    case rate >= easyPassRate:
+        return "Easy"
+    case rate >= mediumPassRate:
+        return "Medium"
+    default:
+        return "Hard"
+    }
+}
+
+var statsCache struct {
+    sync.Mutex
+    stats   map[int]ChallengeStats
+    updated time.Time
+}
+
+// GetChallengeStats returns stats keyed by challenge id. Results are cached
+// for `analytics_cache_seconds` since the challenge list shows them on every
+// page view.
+func GetChallengeStats() map[int]ChallengeStats {
+    statsCache.Lock()
+    defer statsCache.Unlock()
+
+    ttl := time.Duration(web.AppConfig.DefaultInt("analytics_cache_seconds", 60)) * time.Second
+    if statsCache.stats != nil && time.Since(statsCache.updated) < ttl {
+        return statsCache.stats
+    }
+
+    tallies, failures, err := queryChallengeTallies()
+    if err != nil {
+        fmt.Println("Challenge stats not updated:", err)
+        if statsCache.stats != nil {
+            return statsCache.stats
+        }
+        return map[int]ChallengeStats{}
+    }
+
+    o := orm.NewOrm()
+    stats := ComputeChallengeStats(GetAllChallenges(), tallies, failures)
+    for cid, s := range stats {
+        if s.TopFailingCaseId != 0 {
+            tc := TestCase{Id: s.TopFailingCaseId}
+            if o.Read(&tc) == nil {
+                s.TopFailingCase = &tc
+                stats[cid] = s
+            }
+        }
+    }
+
+    statsCache.stats = stats
+    statsCache.updated = time.Now()
+    return statsCache.stats
+}
+
+// ChallengeTally is one challenge's submissions aggregated per visitor.
+type ChallengeTally struct {
+    ChallengeId    int
+    Attempts       int     // Submissions of any outcome
+    Solvers        int     // Distinct visitors
+    Passed         int     // Visitors who passed at least once
+    FirstTry       int     // Visitors whose first submission passed
+    MedianAttempts float64 // Median attempt number of each visitor's first pass
+}
+
+// CaseFailureTally is how many submissions failed a test case.
+type CaseFailureTally struct {
+    ChallengeId int
+    TestCaseId  int
+    Failures    int
+}
+
+// queryChallengeTallies aggregates the submission table in Postgres, so
+// only one row per challenge reaches the app however many submissions
+// there are. The failure query returns each challenge's most failed case.
+func queryChallengeTallies() ([]ChallengeTally, []CaseFailureTally, error) {
+    o := orm.NewOrm()
+
+    var tallies []ChallengeTally
+    _, err := o.Raw(`WITH ordered AS (
+            SELECT challenge_id, ip_hash, passed,
+                row_number() OVER (PARTITION BY challenge_id, ip_hash ORDER BY id) AS n
+            FROM submission WHERE challenge_id IS NOT NULL
+        ), visitors AS (
+            SELECT challenge_id, count(*) AS attempts,
+                bool_or(passed AND n = 1) AS first_passed,
+                min(n) FILTER (WHERE passed) AS passed_after
+            FROM ordered GROUP BY challenge_id, ip_hash
+        )
+        SELECT challenge_id, sum(attempts)::int AS attempts, count(*) AS solvers,
+            count(passed_after) AS passed,
+            count(*) FILTER (WHERE first_passed) AS first_try,
+            coalesce(percentile_cont(0.5) WITHIN GROUP (ORDER BY passed_after), 0) AS median_attempts
+        FROM visitors GROUP BY challenge_id`).QueryRows(&tallies)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    // Results holds the []CaseOutcome JSON written by AddSubmission
+    var failures []CaseFailureTally
+    _, err = o.Raw(`SELECT DISTINCT ON (challenge_id) challenge_id, test_case_id, failures FROM (
+            SELECT s.challenge_id, (r->>'test_case_id')::int AS test_case_id, count(*) AS failures
+            FROM submission s,
+                jsonb_array_elements(CASE WHEN s.results LIKE '[%' THEN s.results::jsonb ELSE '[]'::jsonb END) r
+            WHERE s.challenge_id IS NOT NULL
+                AND NOT (r->>'passed')::boolean
+                AND coalesce((r->>'test_case_id')::int, 0) <> 0
+            GROUP BY 1, 2
+        ) f
+        ORDER BY challenge_id, failures DESC, test_case_id`).QueryRows(&failures)
+    if err != nil {
+        return nil, nil, err
+    }
+    return tallies, failures, nil
+}
+
+// ComputeChallengeStats turns per-challenge tallies into stats, picking the
+// most failed case and checking each challenge's Difficulty label against
+// its first-try pass rate.
+func ComputeChallengeStats(challenges []Challenge, tallies []ChallengeTally, failures []CaseFailureTally) map[int]ChallengeStats {
+    minSolvers := web.AppConfig.DefaultInt("calibration_min_solvers", 10)
+
+    labels := make(map[int]string)
+    for _, c := range challenges {
+        labels[c.Id] = c.Difficulty
+    }
+
+    stats := make(map[int]ChallengeStats)
+    for _, t := range tallies {
+        if t.Solvers == 0 {
+            continue
+        }
+        s := ChallengeStats{
+            ChallengeId:          t.ChallengeId,
+            Attempts:             t.Attempts,
+            Solvers:              t.Solvers,
+            Passed:               t.Passed,
+            FirstTryPassRate:     float64(t.FirstTry) / float64(t.Solvers),
+            MedianAttemptsToPass: t.MedianAttempts,
+        }
+
+        label := labels[t.ChallengeId]
+        observed := ObservedDifficulty(s.FirstTryPassRate)
+        if s.Solvers >= minSolvers && label != "" && !strings.EqualFold(label, observed) {
+            s.Calibration = fmt.Sprintf("Labelled %s, but %.0f%% pass on the first try (looks %s).",
+                label, s.FirstTryPassRate*100, observed)
+        }
+        stats[t.ChallengeId] = s
+    }
+
+    for _, f := range failures {
+        s, ok := stats[f.ChallengeId]
+        if !ok {
+            continue
+        }
+        if f.Failures > s.TopFailingCount || (f.Failures == s.TopFailingCount && f.TestCaseId < s.TopFailingCaseId) {
+            s.TopFailingCaseId, s.TopFailingCount = f.TestCaseId, f.Failures
+            stats[f.ChallengeId] = s
+        }
+    }
+    return stats
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestObservedDifficulty(t *testing.T) {
+    tests := []struct {
+        rate float64
+        want string
+    }{
+        {1, "Easy"},
+        {0.6, "Easy"},
+        {0.59, "Medium"},
+        {0.3, "Medium"},
+        {0.29, "Hard"},
+        {0, "Hard"},
+    }
+
+    for _, tt := range tests {
+        if got := ObservedDifficulty(tt.rate); got != tt.want {
+            t.Errorf("ObservedDifficulty(%v) = %q, want %q", tt.rate, got, tt.want)
+        }
+    }
+}
+
+func TestComputeChallengeStats(t *testing.T) {
+    challenges := []Challenge{
+        {Id: 1, Difficulty: "Easy"},
+        {Id: 2, Difficulty: "Hard"},
+        {Id: 3, Difficulty: "Medium"},
+    }
+
+    tests := []struct {
+        name     string
+        tallies  []ChallengeTally
+        failures []CaseFailureTally
+        want     map[int]ChallengeStats
+        warn     map[int]string // Substring expected in Calibration; "" means none
+    }{
+        {
+            name: "rates and medians carried over",
+            tallies: []ChallengeTally{
+                {ChallengeId: 1, Attempts: 30, Solvers: 10, Passed: 8, FirstTry: 7, MedianAttempts: 1.5},
+            },
+            want: map[int]ChallengeStats{
+                1: {ChallengeId: 1, Attempts: 30, Solvers: 10, Passed: 8, FirstTryPassRate: 0.7, MedianAttemptsToPass: 1.5},
+            },
+            warn: map[int]string{1: ""},
+        },
+        {
+            name: "mislabelled once enough visitors tried",
+            tallies: []ChallengeTally{
+                {ChallengeId: 2, Attempts: 12, Solvers: 10, Passed: 10, FirstTry: 9},
+                {ChallengeId: 1, Attempts: 40, Solvers: 20, Passed: 2, FirstTry: 1},
+            },
+            warn: map[int]string{
+                2: "Labelled Hard, but 90% pass on the first try (looks Easy).",
+                1: "Labelled Easy, but 5% pass on the first try (looks Hard).",
+            },
+        },
+        {
+            name: "too few visitors to judge",
+            tallies: []ChallengeTally{
+                {ChallengeId: 2, Attempts: 3, Solvers: 3, Passed: 3, FirstTry: 3},
+            },
+            warn: map[int]string{2: ""},
+        },
+        {
+            name: "challenges without visitors are left out",
+            tallies: []ChallengeTally{
+                {ChallengeId: 3, Solvers: 0},
+            },
+            failures: []CaseFailureTally{{ChallengeId: 3, TestCaseId: 9, Failures: 4}},
+            want:     map[int]ChallengeStats{},
+        },
+        {
+            name: "most failed case, lowest id on a tie",
+            tallies: []ChallengeTally{
+                {ChallengeId: 3, Attempts: 5, Solvers: 2},
+            },
+            failures: []CaseFailureTally{
+                {ChallengeId: 3, TestCaseId: 12, Failures: 4},
+                {ChallengeId: 3, TestCaseId: 11, Failures: 4},
+                {ChallengeId: 3, TestCaseId: 10, Failures: 1},
+                {ChallengeId: 99, TestCaseId: 1, Failures: 50},
+            },
+            want: map[int]ChallengeStats{
+                3: {ChallengeId: 3, Attempts: 5, Solvers: 2, TopFailingCaseId: 11, TopFailingCount: 4},
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            stats := ComputeChallengeStats(challenges, tt.tallies, tt.failures)
+
+            if tt.want != nil {
+                if len(stats) != len(tt.want) {
+                    t.Errorf("got stats for %d challenges, want %d", len(stats), len(tt.want))
+                }
+                for id, want := range tt.want {
+                    got := stats[id]
+                    got.Calibration = ""
+                    if got != want {
+                        t.Errorf("challenge %d: got %+v, want %+v", id, got, want)
+                    }
+                }
+            }
+
+            for id, warn := range tt.warn {
+                got := stats[id].Calibration
+                if warn == "" && got != "" {
+                    t.Errorf("challenge %d: unexpected calibration warning %q", id, got)
+                }
+                if !strings.Contains(got, warn) {
+                    t.Errorf("challenge %d: calibration %q, want %q", id, got, warn)
+                }
+            }
+        })
+    }
+}
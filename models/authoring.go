@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// ===================================================================================
+// CHALLENGE AUTHORING
+// ===================================================================================
+
+// ValidateChallenge checks the fields an admin-authored challenge needs
+// before it can be saved.
+func ValidateChallenge(c Challenge) error {
+    if strings.TrimSpace(c.Title) == "" {
+        return errors.New("title is required")
+    }
+    if !slugRe.MatchString(c.Slug) {
+        return fmt.Errorf("invalid slug %q: use lower-case letters, digits and '-'", c.Slug)
+    }
+
+    o := orm.NewOrm()
+    if o.QueryTable("challenge").Filter("Slug", c.Slug).Exclude("Id", c.Id).Exist() {
+        return fmt.Errorf("slug %q is already used", c.Slug)
+    }
+
+    switch strings.ToUpper(c.Type) {
+    case TypeCode, TypePredict:
+        lang, err := LookupLanguage(c.Language)
+        if err != nil {
+            return err
+        }
+        if lang.Name == "go" && strings.TrimSpace(c.Generator) != "" {
+            return errors.New("input generators are not supported for Go challenges")
+        }
+    case TypeMCQ:
+        if len(c.ChoiceList()) < 2 {
+            return errors.New("MCQ challenges need at least two choices")
+        }
+    case TypeSQL:
+        if strings.TrimSpace(c.Fixture) == "" {
+            return errors.New("SQL challenges need a fixture")
+        }
+    default:
+        return fmt.Errorf("unknown type %q", c.Type)
+    }
+    return nil
+}
+
+// DeleteChallenge removes a challenge along with its cases, revisions,
+// hints and submissions.
+func DeleteChallenge(id int) error {
+    o := orm.NewOrm()
+    _, err := o.Delete(&Challenge{Id: id})
+    return err
+}
+
+// SlugFromTitle suggests a slug for a new challenge.
+func SlugFromTitle(title string) string {
+    var b strings.Builder
+    dash := false
+    for _, r := range strings.ToLower(title) {
+        switch {
+        case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+            b.WriteRune(r)
+            dash = false
+        case !dash && b.Len() > 0:
+            b.WriteByte('-')
+            dash = true
+        }
+    }
+    return strings.TrimSuffix(b.String(), "-")
+}
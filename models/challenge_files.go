@@ -0,0 +1,332 @@
+package models
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/beego/beego/v2/server/web"
+	"gopkg.in/yaml.v3"
+)
+
+// ===================================================================================
+// CHALLENGE FILES
+// ===================================================================================
+
+// ChallengeFile is one challenge as authored on disk. Markdown files carry the
+// metadata and tests as YAML front matter, the description as the body, and
+// the code under "## Starter Code", "## Reference Solution" and (optionally)
+// "## Input Generator" headings. SQL challenges put their schema and rows
+// under "## Fixture".
+// Plain .yaml files set every field directly.
+//
+// The input generator is always Python, whatever the challenge's language:
+// it returns Python values, which reach JavaScript and TypeScript solutions
+// as JSON literals, so it may only produce JSON-representable arguments. Go
+// challenges can't have a generator.
+//
+// The slug identifies the challenge across renames. It defaults to the file
+// name without its extension or numeric prefix.
+type ChallengeFile struct {
+    Slug              string         `yaml:"slug"`
+    Title             string         `yaml:"title"`
+    Difficulty        string         `yaml:"difficulty"`
+    Category          string         `yaml:"category"`
+    Hint              string         `yaml:"hint"`
+    Function          string         `yaml:"function"`
+    Language          string         `yaml:"language"`
+    Type              string         `yaml:"type"`
+    Description       string         `yaml:"description"`
+    StarterCode       string         `yaml:"starter_code"`
+    ReferenceSolution string         `yaml:"reference_solution"`
+    Generator         string         `yaml:"generator"`
+    PropertyCases     int            `yaml:"property_cases"`
+    Choices           []string       `yaml:"choices"`
+    Answer            string         `yaml:"answer"`
+    Fixture           string         `yaml:"fixture"`
+    Hints             []HintFile     `yaml:"hints"`
+    Tests             []TestCaseFile `yaml:"tests"`
+
+    Path string `yaml:"-"`
+}
+
+type TestCaseFile struct {
+    Input      string  `yaml:"input"`
+    Expected   string  `yaml:"expected"`
+    Comparator string  `yaml:"comparator"`
+    CompareArg string  `yaml:"compare_arg"`
+    Hidden     bool    `yaml:"hidden"`
+    Weight     float64 `yaml:"weight"`
+    Class      string  `yaml:"class"`
+    BudgetMs   float64 `yaml:"budget_ms"`
+}
+
+// HintFile is one entry of `hints:`, either a plain string or a mapping with
+// text and penalty.
+type HintFile struct {
+    Text    string  `yaml:"text"`
+    Penalty float64 `yaml:"penalty"`
+}
+
+func (h *HintFile) UnmarshalYAML(value *yaml.Node) error {
+    if value.Kind == yaml.ScalarNode {
+        h.Text = value.Value
+        return nil
+    }
+    type plain HintFile
+    return value.Decode((*plain)(h))
+}
+
+// HintRows converts the file's hints into (unsaved) Hint rows. Hints without
+// a penalty use `hint_penalty`.
+func (f ChallengeFile) HintRows() []Hint {
+    hints := make([]Hint, len(f.Hints))
+    for i, h := range f.Hints {
+        penalty := h.Penalty
+        if penalty == 0 {
+            penalty = web.AppConfig.DefaultFloat("hint_penalty", 10)
+        }
+        hints[i] = Hint{Position: i + 1, Text: strings.TrimSpace(h.Text), Penalty: penalty}
+    }
+    return hints
+}
+
+// Challenge converts the file into an (unsaved) Challenge row.
+func (f ChallengeFile) Challenge() Challenge {
+    return Challenge{
+        Slug:              f.Slug,
+        Title:             f.Title,
+        Description:       f.Description,
+        InputHint:         f.Hint,
+        FunctionName:      f.Function,
+        Difficulty:        f.Difficulty,
+        Category:          f.Category,
+        Type:              f.Type,
+        Language:          f.Language,
+        StarterCode:       f.StarterCode,
+        ReferenceSolution: f.ReferenceSolution,
+        Generator:         f.Generator,
+        PropertyCases:     f.PropertyCases,
+        Choices:           f.ChoicesJSON(),
+        Answer:            f.Answer,
+        Fixture:           f.Fixture,
+        SeedFile:          filepath.Base(f.Path),
+    }
+}
+
+// ChoicesJSON encodes the MCQ choices as stored in Challenge.Choices.
+func (f ChallengeFile) ChoicesJSON() string {
+    if len(f.Choices) == 0 {
+        return ""
+    }
+    b, _ := json.Marshal(f.Choices)
+    return string(b)
+}
+
+// TestCases converts the file's tests into (unsaved) TestCase rows.
+func (f ChallengeFile) TestCases() []TestCase {
+    cases := make([]TestCase, len(f.Tests))
+    for i, t := range f.Tests {
+        weight := t.Weight
+        if weight == 0 {
+            weight = 1
+        }
+        comparator := t.Comparator
+        if comparator == "" {
+            comparator = CompareExact
+        }
+        class := t.Class
+        if class == "" {
+            class = ClassCorrectness
+        }
+        cases[i] = TestCase{
+            InputArgs:      t.Input,
+            ExpectedOutput: t.Expected,
+            Comparator:     comparator,
+            CompareArg:     t.CompareArg,
+            Hidden:         t.Hidden,
+            Weight:         weight,
+            Class:          class,
+            BudgetMs:       t.BudgetMs,
+        }
+    }
+    return cases
+}
+
+// LoadChallengeFiles reads every .md, .yaml and .yml file in dir, sorted by
+// file name so numeric prefixes control display order.
+func LoadChallengeFiles(dir string) ([]ChallengeFile, error) {
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        return nil, err
+    }
+
+    var names []string
+    for _, e := range entries {
+        switch strings.ToLower(filepath.Ext(e.Name())) {
+        case ".md", ".yaml", ".yml":
+            if !e.IsDir() {
+                names = append(names, e.Name())
+            }
+        }
+    }
+    sort.Strings(names)
+
+    var files []ChallengeFile
+    slugs := make(map[string]string)
+    for _, name := range names {
+        f, err := ParseChallengeFile(filepath.Join(dir, name))
+        if err != nil {
+            return nil, fmt.Errorf("%s: %v", name, err)
+        }
+        if other, ok := slugs[f.Slug]; ok {
+            return nil, fmt.Errorf("%s: slug %q is already used by %s", name, f.Slug, other)
+        }
+        slugs[f.Slug] = name
+        files = append(files, f)
+    }
+    return files, nil
+}
+
+func ParseChallengeFile(path string) (ChallengeFile, error) {
+    var f ChallengeFile
+
+    raw, err := os.ReadFile(path)
+    if err != nil {
+        return f, err
+    }
+    src := strings.ReplaceAll(string(raw), "\r\n", "\n")
+
+    if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+        err = yaml.Unmarshal([]byte(src), &f)
+    } else {
+        err = parseMarkdownChallenge(src, &f)
+    }
+    if err != nil {
+        return f, err
+    }
+
+    f.Path = path
+    if f.Title == "" {
+        return f, errors.New("missing title")
+    }
+    if f.Slug == "" {
+        f.Slug = slugFromPath(path)
+    }
+    if !slugRe.MatchString(f.Slug) {
+        return f, fmt.Errorf("invalid slug %q: use lower-case letters, digits and '-'", f.Slug)
+    }
+    if f.Type == "" {
+        f.Type = TypeCode
+    }
+    f.Type = strings.ToUpper(f.Type)
+    if f.Language == "" {
+        f.Language = defaultLanguage
+    }
+    if strings.TrimSpace(f.Generator) != "" && strings.EqualFold(f.Language, "go") {
+        return f, errors.New("input generators are not supported for Go challenges")
+    }
+    if err := CheckTimedCases(f.Language, f.TestCases()); err != nil {
+        return f, err
+    }
+    return f, nil
+}
+
+var (
+    slugRe       = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+    slugPrefixRe = regexp.MustCompile(`^\d+[-_]`)
+)
+
+// slugFromPath turns "04-the-lost-penny-problem.md" into
+// "the-lost-penny-problem".
+func slugFromPath(path string) string {
+    name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+    name = slugPrefixRe.ReplaceAllString(strings.ToLower(name), "")
+    return strings.ReplaceAll(name, "_", "-")
+}
+
+func parseMarkdownChallenge(src string, f *ChallengeFile) error {
+    if !strings.HasPrefix(src, "---\n") {
+        return errors.New("missing front matter")
+    }
+    end := strings.Index(src[4:], "\n---\n")
+    if end < 0 {
+        return errors.New("unterminated front matter")
+    }
+
+    if err := yaml.Unmarshal([]byte(src[4:4+end]), f); err != nil {
+        return err
+    }
+
+    sections := splitSections(src[4+end+5:])
+    if f.Description == "" {
+        f.Description = sections[""]
+    }
+    if f.StarterCode == "" {
+        f.StarterCode = fencedCode(sections["starter code"])
+    }
+    if f.ReferenceSolution == "" {
+        f.ReferenceSolution = fencedCode(sections["reference solution"])
+    }
+    if f.Generator == "" {
+        f.Generator = fencedCode(sections["input generator"])
+    }
+    if f.Fixture == "" {
+        f.Fixture = fencedCode(sections["fixture"])
+    }
+    return nil
+}
+
+// splitSections maps each lower-cased "## " heading to the text beneath it.
+// Text before the first heading is stored under "".
+func splitSections(body string) map[string]string {
+    sections := make(map[string]string)
+    current := ""
+    var buf strings.Builder
+    inFence := false
+
+    flush := func() {
+        sections[current] = strings.TrimSpace(buf.String())
+        buf.Reset()
+    }
+
+    scanner := bufio.NewScanner(strings.NewReader(body))
+    for scanner.Scan() {
+        line := scanner.Text()
+        if strings.HasPrefix(line, "```") {
+            inFence = !inFence
+        }
+        if !inFence && strings.HasPrefix(line, "## ") {
+            flush()
+            current = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "## ")))
+            continue
+        }
+        buf.WriteString(line + "\n")
+    }
+    flush()
+
+    return sections
+}
+
+// fencedCode returns the contents of the first ``` block in text, or the
+// whole text if it has none.
+func fencedCode(text string) string {
+    lines := strings.Split(text, "\n")
+    start := -1
+    for i, line := range lines {
+        if strings.HasPrefix(line, "```") {
+            if start < 0 {
+                start = i
+                continue
+            }
+            return strings.Join(lines[start+1:i], "\n")
+        }
+    }
+    return text
+}
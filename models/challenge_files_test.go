@@ -0,0 +1,182 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const markdownChallenge = `---
+title: "Sum Two"
+difficulty: Easy
+function: add
+tests:
+  - input: "1, 2"
+    expected: "3"
+  - input: "0.1, 0.2"
+    expected: "0.3"
+    comparator: float
+    hidden: true
+    weight: 2
+hints:
+  - Think about addition.
+  - text: Use the + operator.
+    penalty: 25
+---
+
+Add two numbers.
+
+## Starter Code
+
+` + "```python\ndef add(a, b):\n    pass\n```" + `
+
+## Reference Solution
+
+` + "```python\n## not a heading inside a fence\ndef add(a, b):\n    return a + b\n```" + `
+`
+
+func TestParseChallengeFile(t *testing.T) {
+    tests := []struct {
+        name    string
+        file    string
+        src     string
+        wantErr string
+        check   func(t *testing.T, f ChallengeFile)
+    }{
+        {
+            name: "markdown front matter and sections",
+            file: "04-sum_two.md",
+            src:  markdownChallenge,
+            check: func(t *testing.T, f ChallengeFile) {
+                if f.Slug != "sum-two" {
+                    t.Errorf("Slug = %q", f.Slug)
+                }
+                if f.Type != TypeCode || f.Language != defaultLanguage {
+                    t.Errorf("Type, Language = %q, %q; want defaults", f.Type, f.Language)
+                }
+                if f.Description != "Add two numbers." {
+                    t.Errorf("Description = %q", f.Description)
+                }
+                if f.StarterCode != "def add(a, b):\n    pass" {
+                    t.Errorf("StarterCode = %q", f.StarterCode)
+                }
+                if !strings.Contains(f.ReferenceSolution, "## not a heading") || !strings.Contains(f.ReferenceSolution, "return a + b") {
+                    t.Errorf("ReferenceSolution = %q", f.ReferenceSolution)
+                }
+
+                cases := f.TestCases()
+                if len(cases) != 2 {
+                    t.Fatalf("got %d test cases", len(cases))
+                }
+                if cases[0].Comparator != CompareExact || cases[0].Weight != 1 || cases[0].Class != ClassCorrectness {
+                    t.Errorf("case 1 defaults = %+v", cases[0])
+                }
+                if cases[1].Comparator != CompareFloat || !cases[1].Hidden || cases[1].Weight != 2 {
+                    t.Errorf("case 2 = %+v", cases[1])
+                }
+
+                hints := f.HintRows()
+                if len(hints) != 2 || hints[0].Text != "Think about addition." || hints[1].Penalty != 25 || hints[1].Position != 2 {
+                    t.Errorf("hints = %+v", hints)
+                }
+            },
+        },
+        {
+            name: "yaml file with explicit slug",
+            file: "whatever.yaml",
+            src:  "slug: custom-slug\ntitle: Pick One\ntype: mcq\nchoices: [a, b]\nanswer: a\n",
+            check: func(t *testing.T, f ChallengeFile) {
+                if f.Slug != "custom-slug" || f.Type != "MCQ" {
+                    t.Errorf("Slug, Type = %q, %q", f.Slug, f.Type)
+                }
+                if f.ChoicesJSON() != `["a","b"]` {
+                    t.Errorf("ChoicesJSON = %q", f.ChoicesJSON())
+                }
+            },
+        },
+        {
+            name: "windows line endings",
+            file: "crlf.md",
+            src:  strings.ReplaceAll("---\ntitle: CRLF\n---\n\nBody\n", "\n", "\r\n"),
+            check: func(t *testing.T, f ChallengeFile) {
+                if f.Title != "CRLF" || f.Description != "Body" {
+                    t.Errorf("Title, Description = %q, %q", f.Title, f.Description)
+                }
+            },
+        },
+        {name: "missing front matter", file: "a.md", src: "# Title\n", wantErr: "missing front matter"},
+        {name: "unterminated front matter", file: "a.md", src: "---\ntitle: x\n", wantErr: "unterminated front matter"},
+        {name: "missing title", file: "a.md", src: "---\ndifficulty: Easy\n---\n", wantErr: "missing title"},
+        {name: "invalid slug", file: "a.md", src: "---\ntitle: x\nslug: Bad Slug\n---\n", wantErr: "invalid slug"},
+        {
+            name:    "generator on a go challenge",
+            file:    "a.md",
+            src:     "---\ntitle: x\nlanguage: go\n---\n\n## Input Generator\n\n```python\ndef generate(rng):\n    return [1]\n```\n",
+            wantErr: "not supported for Go",
+        },
+        {
+            name:    "performance case on a javascript challenge",
+            file:    "a.yaml",
+            src:     "title: x\nlanguage: javascript\ntests:\n  - input: \"1\"\n    expected: \"1\"\n  - input: \"2\"\n    expected: \"2\"\n    class: performance\n",
+            wantErr: "test 2: performance cases and time budgets are only supported for Python",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            path := filepath.Join(t.TempDir(), tt.file)
+            if err := os.WriteFile(path, []byte(tt.src), 0o644); err != nil {
+                t.Fatal(err)
+            }
+
+            f, err := ParseChallengeFile(path)
+            if tt.wantErr != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                    t.Fatalf("error = %v, want %q", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatal(err)
+            }
+            tt.check(t, f)
+        })
+    }
+}
+
+func TestSlugFromPath(t *testing.T) {
+    tests := map[string]string{
+        "04-the-lost-penny-problem.md":   "the-lost-penny-problem",
+        "challenges/12_Job_Cascade.yaml": "job-cascade",
+        "no-prefix.yml":                  "no-prefix",
+        "2024-release.md":                "release",
+    }
+
+    for path, want := range tests {
+        if got := slugFromPath(path); got != want {
+            t.Errorf("slugFromPath(%q) = %q, want %q", path, got, want)
+        }
+    }
+}
+
+func TestLoadChallengeFilesDuplicateSlug(t *testing.T) {
+    dir := t.TempDir()
+    for _, name := range []string{"01-dup.md", "02-dup.md"} {
+        os.WriteFile(filepath.Join(dir, name), []byte("---\ntitle: x\nslug: dup\n---\n"), 0o644)
+    }
+    if _, err := LoadChallengeFiles(dir); err == nil || !strings.Contains(err.Error(), `slug "dup" is already used`) {
+        t.Errorf("error = %v, want duplicate slug", err)
+    }
+}
+
+// TestSeedChallengesParse keeps the shipped challenge files loadable.
+func TestSeedChallengesParse(t *testing.T) {
+    files, err := LoadChallengeFiles(filepath.Join("..", "challenges"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(files) == 0 {
+        t.Fatal("no challenge files found")
+    }
+}
@@ -0,0 +1,287 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/server/web"
+	"github.com/mattn/go-sqlite3"
+)
+
+// ===================================================================================
+// NON-CODE CHALLENGE TYPES
+// ===================================================================================
+
+// Challenge.Type values. CODE challenges run through the executor and job
+// queue; the others are graded in-process, synchronously.
+const (
+    TypeCode    = "CODE"    // Implement a function, graded by test cases
+    TypeMCQ     = "MCQ"     // Pick one of Choices; Answer is the correct choice text
+    TypeSQL     = "SQL"     // Write a query against Fixture; compared with ReferenceSolution's rows
+    TypePredict = "PREDICT" // Read StarterCode and type its output; Answer is that output
+)
+
+// IsCode reports whether c is graded by running submitted code.
+func (c Challenge) IsCode() bool {
+    return c.Type == "" || strings.EqualFold(c.Type, TypeCode)
+}
+
+// ChoiceList decodes the MCQ choices.
+func (c Challenge) ChoiceList() []string {
+    var choices []string
+    json.Unmarshal([]byte(c.Choices), &choices)
+    return choices
+}
+
+// --- Multiple Choice ---
+
+// GradeChoice grades the choice at index (as displayed, 0-based).
+func GradeChoice(c Challenge, index int) (GradeReport, error) {
+    choices := c.ChoiceList()
+    if index < 0 || index >= len(choices) {
+        return GradeReport{}, errors.New("no such choice")
+    }
+
+    picked := choices[index]
+    if strings.TrimSpace(picked) == strings.TrimSpace(c.Answer) {
+        return GradeReport{Passed: true, Score: 100, Output: fmt.Sprintf("✓ CORRECT: %s\n", picked)}, nil
+    }
+    return GradeReport{Output: fmt.Sprintf("✗ INCORRECT: %s\n", picked)}, nil
+}
+
+// --- Output Prediction ---
+
+// GradePrediction compares a typed prediction with the snippet's output,
+// ignoring trailing whitespace on each line and surrounding blank lines.
+func GradePrediction(c Challenge, prediction string) GradeReport {
+    if normalizeOutput(prediction) == normalizeOutput(c.Answer) {
+        return GradeReport{Passed: true, Score: 100, Output: "✓ CORRECT: output matches.\n"}
+    }
+    return GradeReport{Output: fmt.Sprintf("✗ INCORRECT:\n  Got: %s\n", strings.TrimSpace(prediction))}
+}
+
+func normalizeOutput(s string) string {
+    lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+    for i, line := range lines {
+        lines[i] = strings.TrimRight(line, " \t")
+    }
+    return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// --- SQL ---
+
+// Only a single read query may be submitted. The database is a throwaway
+// in-memory copy of the fixture, but ATTACH and PRAGMA could still reach
+// the filesystem or change engine behaviour.
+var (
+    sqlReadRe    = regexp.MustCompile(`(?is)^\s*(select|with)\b`)
+    sqlCommentRe = regexp.MustCompile(`(?s)^\s*(--[^\n]*|/\*.*?\*/)`)
+    sqlDeniedRe  = regexp.MustCompile(`(?i)\b(attach|detach|pragma|load_extension|vacuum)\b`)
+    sqlOrderRe   = regexp.MustCompile(`(?i)\border\s+by\b`)
+)
+
+const sqlMaxRows = 1000
+
+// GradeSQL runs query and the challenge's reference query against fresh
+// copies of the fixture and compares their result sets. Row order only
+// matters when the reference query has an ORDER BY.
+func GradeSQL(c Challenge, query string) (GradeReport, error) {
+    for sqlCommentRe.MatchString(query) {
+        query = sqlCommentRe.ReplaceAllString(query, "")
+    }
+    query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+    switch {
+    case !sqlReadRe.MatchString(query):
+        return GradeReport{Output: "✗ REJECTED: only a single SELECT (or WITH ... SELECT) query is allowed.\n"}, nil
+    case strings.Contains(query, ";"):
+        return GradeReport{Output: "✗ REJECTED: submit one statement at a time.\n"}, nil
+    case sqlDeniedRe.MatchString(query):
+        return GradeReport{Output: fmt.Sprintf("✗ REJECTED: %q is not allowed.\n", sqlDeniedRe.FindString(query))}, nil
+    }
+
+    want, err := runFixtureQuery(c.Fixture, c.ReferenceSolution)
+    if err != nil {
+        return GradeReport{}, fmt.Errorf("reference query: %v", err)
+    }
+
+    got, err := runFixtureQuery(c.Fixture, query)
+    if err != nil {
+        return GradeReport{Output: fmt.Sprintf("ERROR:\n%s\n", err)}, nil
+    }
+
+    ordered := sqlOrderRe.MatchString(c.ReferenceSolution)
+    var out strings.Builder
+    passed := sameRows(want, got, ordered)
+    switch {
+    case passed:
+        out.WriteString(fmt.Sprintf("✓ PASS: %d rows match.\n", len(got.rows)))
+    case len(want.columns) != len(got.columns):
+        out.WriteString(fmt.Sprintf("✗ FAIL: expected %d columns, got %d.\n", len(want.columns), len(got.columns)))
+    case len(want.rows) != len(got.rows):
+        out.WriteString(fmt.Sprintf("✗ FAIL: expected %d rows, got %d.\n", len(want.rows), len(got.rows)))
+    case ordered && sameRows(want, got, false):
+        out.WriteString("✗ FAIL: right rows, wrong order.\n")
+    default:
+        out.WriteString("✗ FAIL: row values differ from the expected result.\n")
+    }
+    out.WriteString(got.String())
+
+    report := GradeReport{Passed: passed, Output: out.String()}
+    if passed {
+        report.Score = 100
+    }
+    return report, nil
+}
+
+type resultSet struct {
+    columns []string
+    rows    [][]string
+}
+
+// String renders up to 20 rows as a pipe-separated table.
+func (r resultSet) String() string {
+    var b strings.Builder
+    b.WriteString(strings.Join(r.columns, " | ") + "\n")
+    for i, row := range r.rows {
+        if i == 20 {
+            b.WriteString(fmt.Sprintf("... %d more rows\n", len(r.rows)-20))
+            break
+        }
+        b.WriteString(strings.Join(row, " | ") + "\n")
+    }
+    return b.String()
+}
+
+func sameRows(a, b resultSet, ordered bool) bool {
+    if len(a.columns) != len(b.columns) || len(a.rows) != len(b.rows) {
+        return false
+    }
+    x, y := joinRows(a.rows), joinRows(b.rows)
+    if !ordered {
+        sort.Strings(x)
+        sort.Strings(y)
+    }
+    for i := range x {
+        if x[i] != y[i] {
+            return false
+        }
+    }
+    return true
+}
+
+// limitSQLite caps what untrusted SQL can allocate before the timeout
+// fires: strings and blobs at `sql_max_length_kb`, and all SQLite memory at
+// `sql_heap_mb`. The heap limit is process-wide, which is fine as SQL
+// challenges are the only SQLite user; the app database is Postgres.
+func limitSQLite(ctx context.Context, conn *sql.Conn) error {
+    maxLength := web.AppConfig.DefaultInt("sql_max_length_kb", 1024) * 1024
+    err := conn.Raw(func(driverConn interface{}) error {
+        c, ok := driverConn.(*sqlite3.SQLiteConn)
+        if !ok {
+            return errors.New("unexpected sqlite driver connection")
+        }
+        c.SetLimit(sqlite3.SQLITE_LIMIT_LENGTH, maxLength)
+        c.SetLimit(sqlite3.SQLITE_LIMIT_SQL_LENGTH, maxLength)
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+
+    heap := web.AppConfig.DefaultInt("sql_heap_mb", 64) * 1024 * 1024
+    _, err = conn.ExecContext(ctx, fmt.Sprintf("PRAGMA hard_heap_limit = %d", heap))
+    return err
+}
+
+func joinRows(rows [][]string) []string {
+    out := make([]string, len(rows))
+    for i, row := range rows {
+        out[i] = strings.Join(row, "\x1f")
+    }
+    return out
+}
+
+// runFixtureQuery loads fixture into a private in-memory database and runs
+// query against it, bounded by `sql_timeout_ms` and the memory limits in
+// limitSQLite.
+func runFixtureQuery(fixture, query string) (resultSet, error) {
+    var rs resultSet
+
+    db, err := sql.Open("sqlite3", ":memory:")
+    if err != nil {
+        return rs, err
+    }
+    defer db.Close()
+    // Each connection gets its own :memory: database; keep to one.
+    db.SetMaxOpenConns(1)
+
+    timeout := time.Duration(web.AppConfig.DefaultInt("sql_timeout_ms", 2000)) * time.Millisecond
+    ctx, cancel := context.WithTimeout(context.Background(), timeout)
+    defer cancel()
+
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return rs, err
+    }
+    defer conn.Close()
+    if err := limitSQLite(ctx, conn); err != nil {
+        return rs, err
+    }
+
+    if _, err := conn.ExecContext(ctx, fixture); err != nil {
+        return rs, fmt.Errorf("fixture: %v", err)
+    }
+    if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+        return rs, err
+    }
+
+    rows, err := conn.QueryContext(ctx, query)
+    if err != nil {
+        return rs, err
+    }
+    defer rows.Close()
+
+    if rs.columns, err = rows.Columns(); err != nil {
+        return rs, err
+    }
+
+    for rows.Next() {
+        if len(rs.rows) == sqlMaxRows {
+            return rs, fmt.Errorf("result has more than %d rows", sqlMaxRows)
+        }
+        values := make([]interface{}, len(rs.columns))
+        ptrs := make([]interface{}, len(values))
+        for i := range values {
+            ptrs[i] = &values[i]
+        }
+        if err := rows.Scan(ptrs...); err != nil {
+            return rs, err
+        }
+
+        row := make([]string, len(values))
+        for i, v := range values {
+            switch v := v.(type) {
+            case nil:
+                row[i] = "NULL"
+            case []byte:
+                row[i] = string(v)
+            case float64:
+                row[i] = fmt.Sprintf("%.6g", v)
+            default:
+                row[i] = fmt.Sprint(v)
+            }
+        }
+        rs.rows = append(rs.rows, row)
+    }
+    if ctx.Err() != nil {
+        return rs, errors.New("query timed out")
+    }
+    return rs, rows.Err()
+}
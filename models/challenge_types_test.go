@@ -0,0 +1,171 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+func TestGradeChoice(t *testing.T) {
+    c := Challenge{Type: TypeMCQ, Choices: `["O(n)", "O(log n)", "O(1)"]`, Answer: " O(log n) "}
+
+    tests := []struct {
+        index      int
+        wantPassed bool
+        wantLog    string
+        wantErr    bool
+    }{
+        {index: 1, wantPassed: true, wantLog: "✓ CORRECT: O(log n)"},
+        {index: 0, wantLog: "✗ INCORRECT: O(n)"},
+        {index: 3, wantErr: true},
+        {index: -1, wantErr: true},
+    }
+
+    for _, tt := range tests {
+        report, err := GradeChoice(c, tt.index)
+        if tt.wantErr {
+            if err == nil {
+                t.Errorf("choice %d: no error", tt.index)
+            }
+            continue
+        }
+        if err != nil {
+            t.Fatal(err)
+        }
+        if report.Passed != tt.wantPassed || !strings.Contains(report.Output, tt.wantLog) {
+            t.Errorf("choice %d: got %+v", tt.index, report)
+        }
+        if wantScore := map[bool]float64{true: 100}[tt.wantPassed]; report.Score != wantScore {
+            t.Errorf("choice %d: score = %v, want %v", tt.index, report.Score, wantScore)
+        }
+    }
+}
+
+func TestGradePrediction(t *testing.T) {
+    c := Challenge{Type: TypePredict, Answer: "1\n2\n"}
+
+    tests := []struct {
+        prediction string
+        want       bool
+    }{
+        {"1\n2", true},
+        {"\n1  \r\n2\t\n\n", true}, // Trailing whitespace, CRLF and blank lines
+        {"1 2", false},
+        {" 1\n2", false}, // Leading whitespace matters
+        {"", false},
+    }
+
+    for _, tt := range tests {
+        if got := GradePrediction(c, tt.prediction); got.Passed != tt.want {
+            t.Errorf("GradePrediction(%q) passed = %v, want %v", tt.prediction, got.Passed, tt.want)
+        }
+    }
+}
+
+func TestGradeSQL(t *testing.T) {
+    fixture := `CREATE TABLE employee (id INTEGER, name TEXT, dept TEXT, salary REAL);
+INSERT INTO employee VALUES (1, 'Ada', 'eng', 120), (2, 'Bob', 'ops', 80), (3, 'Cy', 'eng', 100);`
+    unordered := Challenge{Type: TypeSQL, Fixture: fixture, ReferenceSolution: "SELECT name FROM employee WHERE dept = 'eng'"}
+    ordered := Challenge{Type: TypeSQL, Fixture: fixture, ReferenceSolution: "SELECT name FROM employee ORDER BY salary DESC"}
+
+    tests := []struct {
+        name       string
+        c          Challenge
+        query      string
+        wantPassed bool
+        wantLog    string
+    }{
+        {"same rows", unordered, "select name from employee where salary >= 100;", true, "✓ PASS: 2 rows match."},
+        {"order ignored without ORDER BY", unordered, "SELECT name FROM employee WHERE dept = 'eng' ORDER BY name DESC", true, "✓ PASS"},
+        {"leading comment", unordered, "-- eng only\n/* names */ SELECT name FROM employee WHERE dept = 'eng'", true, "✓ PASS"},
+        {"wrong order", ordered, "SELECT name FROM employee ORDER BY salary", false, "right rows, wrong order"},
+        {"wrong column count", unordered, "SELECT id, name FROM employee WHERE dept = 'eng'", false, "expected 1 columns, got 2"},
+        {"wrong row count", unordered, "SELECT name FROM employee", false, "expected 2 rows, got 3"},
+        {"wrong values", unordered, "SELECT dept FROM employee WHERE dept = 'eng'", false, "row values differ"},
+        {"query error", unordered, "SELECT nope FROM employee", false, "ERROR:\nno such column: nope"},
+        {"write", unordered, "DELETE FROM employee", false, "only a single SELECT"},
+        {"write in a CTE", unordered, "WITH x AS (SELECT 1) DELETE FROM employee", false, "ERROR:"},
+        {"two statements", unordered, "SELECT 1; SELECT 2", false, "one statement at a time"},
+        {"load_extension", unordered, "SELECT load_extension('/tmp/evil.so')", false, `"load_extension" is not allowed`},
+        {"attach", unordered, "SELECT 1 FROM employee WHERE 'x' = 'attach'", false, `"attach" is not allowed`},
+        {"too many rows", unordered, "WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n LIMIT 5000) SELECT x FROM n", false, "more than 1000 rows"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            report, err := GradeSQL(tt.c, tt.query)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if report.Passed != tt.wantPassed {
+                t.Errorf("passed = %v, want %v\n%s", report.Passed, tt.wantPassed, report.Output)
+            }
+            if !strings.Contains(report.Output, tt.wantLog) {
+                t.Errorf("output = %q, want it to contain %q", report.Output, tt.wantLog)
+            }
+        })
+    }
+}
+
+func TestGradeSQLLimits(t *testing.T) {
+    defer web.AppConfig.Set("sql_timeout_ms", "")
+    web.AppConfig.Set("sql_timeout_ms", "200")
+    c := Challenge{Type: TypeSQL, Fixture: "CREATE TABLE t (x INTEGER);", ReferenceSolution: "SELECT x FROM t"}
+
+    report, err := GradeSQL(c, "WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n) SELECT count(*) FROM n")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if report.Passed || !strings.Contains(report.Output, "ERROR:") {
+        t.Errorf("runaway query: %q, want it stopped", report.Output)
+    }
+
+    report, err = GradeSQL(c, "SELECT length(zeroblob(1 << 30))")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if report.Passed || !strings.Contains(report.Output, "ERROR:") {
+        t.Errorf("1GB blob: %q, want it refused", report.Output)
+    }
+
+    c.ReferenceSolution = "SELECT nope FROM t"
+    if _, err := GradeSQL(c, "SELECT x FROM t"); err == nil || !strings.Contains(err.Error(), "reference query") {
+        t.Errorf("error = %v, want the broken reference reported", err)
+    }
+}
+
+func TestVerifyReferenceAnswers(t *testing.T) {
+    prints := func(stdout string) *FakeExecutor {
+        return NewFakeExecutor(func(PistonRequest) (PistonResponse, error) {
+            return PistonResponse{Run: PistonRun{Stdout: stdout}}, nil
+        })
+    }
+
+    tests := []struct {
+        name     string
+        c        Challenge
+        executor Executor
+        wantErr  string
+    }{
+        {"mcq answer among choices", Challenge{Type: TypeMCQ, Choices: `["a", "b"]`, Answer: "b"}, nil, ""},
+        {"mcq answer missing", Challenge{Type: TypeMCQ, Choices: `["a", "b"]`, Answer: "c"}, nil, "not one of the choices"},
+        {"prediction matches", Challenge{Type: TypePredict, StarterCode: "print(3)", Answer: "3"}, prints("3\n"), ""},
+        {"prediction differs", Challenge{Type: TypePredict, StarterCode: "print(3)", Answer: "4"}, prints("3\n"), "snippet prints:\n3"},
+        {"sql reference runs", Challenge{Type: TypeSQL, Fixture: "CREATE TABLE t (x);", ReferenceSolution: "SELECT x FROM t"}, nil, ""},
+        {"sql reference broken", Challenge{Type: TypeSQL, Fixture: "CREATE TABLE t (x);", ReferenceSolution: "SELECT y FROM t"}, nil, "no such column"},
+    }
+
+    for _, tt := range tests {
+        err := VerifyReference(tt.executor, tt.c, nil)
+        if tt.wantErr == "" {
+            if err != nil {
+                t.Errorf("%s: %v", tt.name, err)
+            }
+            continue
+        }
+        if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+            t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+        }
+    }
+}
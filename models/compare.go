@@ -0,0 +1,340 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ===================================================================================
+// TEST CASE COMPARATORS
+// ===================================================================================
+
+// Comparator modes stored on TestCase.Comparator. An empty value means exact.
+const (
+    CompareExact      = "exact"      // Trimmed string equality
+    CompareFloat      = "float"      // Numeric values within CompareArg tolerance (default 1e-6)
+    CompareUnordered  = "unordered"  // Lists holding the same elements in any order
+    CompareStructural = "structural" // Equal Python/JSON literals, ignoring quoting, spacing and dict order
+    CompareRegex      = "regex"      // ExpectedOutput is a pattern the whole output must match
+)
+
+// CompareOutput reports whether actual satisfies the test case's expected
+// output under its comparator. Outputs that can't be parsed as literals fall
+// back to exact comparison.
+func CompareOutput(tc TestCase, actual string) bool {
+    actual = strings.TrimSpace(actual)
+    expected := strings.TrimSpace(tc.ExpectedOutput)
+
+    switch tc.Comparator {
+    case CompareRegex:
+        re, err := regexp.Compile(`^(?:` + expected + `)$`)
+        return err == nil && re.MatchString(actual)
+
+    case CompareFloat, CompareUnordered, CompareStructural:
+        want, errWant := ParseLiteral(expected)
+        got, errGot := ParseLiteral(actual)
+        if errWant != nil || errGot != nil {
+            return actual == expected
+        }
+
+        tolerance := 0.0
+        if tc.Comparator == CompareFloat {
+            tolerance = 1e-6
+            if t, err := strconv.ParseFloat(strings.TrimSpace(tc.CompareArg), 64); err == nil {
+                tolerance = t
+            }
+        }
+
+        if tc.Comparator == CompareUnordered {
+            return unorderedEqual(want, got, tolerance)
+        }
+        return literalEqual(want, got, tolerance)
+
+    default:
+        return actual == expected
+    }
+}
+
+func literalEqual(a, b interface{}, tolerance float64) bool {
+    switch av := a.(type) {
+    case float64:
+        bv, ok := b.(float64)
+        return ok && math.Abs(av-bv) <= tolerance
+    case []interface{}:
+        bv, ok := b.([]interface{})
+        if !ok || len(av) != len(bv) {
+            return false
+        }
+        for i := range av {
+            if !literalEqual(av[i], bv[i], tolerance) {
+                return false
+            }
+        }
+        return true
+    case literalDict:
+        bv, ok := b.(literalDict)
+        if !ok || len(av) != len(bv) {
+            return false
+        }
+        for k, v := range av {
+            other, ok := bv[k]
+            if !ok || !literalEqual(v, other, tolerance) {
+                return false
+            }
+        }
+        return true
+    default:
+        return a == b
+    }
+}
+
+// unorderedEqual compares two lists as multisets by sorting both on their
+// canonical form before an element-wise comparison.
+func unorderedEqual(a, b interface{}, tolerance float64) bool {
+    al, okA := a.([]interface{})
+    bl, okB := b.([]interface{})
+    if !okA || !okB {
+        return literalEqual(a, b, tolerance)
+    }
+    if len(al) != len(bl) {
+        return false
+    }
+    return literalEqual(sortedCopy(al), sortedCopy(bl), tolerance)
+}
+
+func sortedCopy(list []interface{}) []interface{} {
+    out := append([]interface{}(nil), list...)
+    sort.SliceStable(out, func(i, j int) bool {
+        return canonicalLiteral(out[i]) < canonicalLiteral(out[j])
+    })
+    return out
+}
+
+// --- Literal Parsing ---
+
+// literalDict holds a parsed dict/object keyed by the canonical form of each
+// key, so {1: 'a'} and {'b': 2} keys compare consistently.
+type literalDict map[string]interface{}
+
+// ParseLiteral reads a Python repr or JSON value: numbers, strings, booleans,
+// None/null, lists, tuples, sets and dicts. Numbers become float64, tuples
+// become lists and sets become sorted lists.
+func ParseLiteral(s string) (interface{}, error) {
+    p := &literalParser{src: s}
+    v, err := p.value()
+    if err != nil {
+        return nil, err
+    }
+    p.skipSpace()
+    if p.pos != len(p.src) {
+        return nil, fmt.Errorf("unexpected %q at offset %d", p.src[p.pos:], p.pos)
+    }
+    return v, nil
+}
+
+type literalParser struct {
+    src string
+    pos int
+}
+
+func (p *literalParser) skipSpace() {
+    for p.pos < len(p.src) && strings.ContainsRune(" \t\r\n", rune(p.src[p.pos])) {
+        p.pos++
+    }
+}
+
+func (p *literalParser) value() (interface{}, error) {
+    p.skipSpace()
+    if p.pos >= len(p.src) {
+        return nil, errors.New("unexpected end of input")
+    }
+
+    switch ch := p.src[p.pos]; {
+    case ch == '[':
+        p.pos++
+        return p.sequence(']')
+    case ch == '(':
+        p.pos++
+        return p.sequence(')')
+    case ch == '{':
+        p.pos++
+        return p.mapping()
+    case ch == '\'' || ch == '"':
+        return p.str()
+    default:
+        return p.atom()
+    }
+}
+
+func (p *literalParser) sequence(end byte) (interface{}, error) {
+    items := []interface{}{}
+    for {
+        p.skipSpace()
+        if p.pos < len(p.src) && p.src[p.pos] == end {
+            p.pos++
+            return items, nil
+        }
+
+        item, err := p.value()
+        if err != nil {
+            return nil, err
+        }
+        items = append(items, item)
+
+        p.skipSpace()
+        if p.pos < len(p.src) && p.src[p.pos] == ',' {
+            p.pos++
+        } else if p.pos >= len(p.src) || p.src[p.pos] != end {
+            return nil, fmt.Errorf("expected ',' or %q at offset %d", end, p.pos)
+        }
+    }
+}
+
+// mapping parses a dict, or a set if the first entry has no colon.
+func (p *literalParser) mapping() (interface{}, error) {
+    dict := literalDict{}
+    var set []interface{}
+    for {
+        p.skipSpace()
+        if p.pos < len(p.src) && p.src[p.pos] == '}' {
+            p.pos++
+            if set != nil {
+                return sortedCopy(set), nil
+            }
+            return dict, nil
+        }
+
+        key, err := p.value()
+        if err != nil {
+            return nil, err
+        }
+
+        p.skipSpace()
+        if p.pos < len(p.src) && p.src[p.pos] == ':' && set == nil {
+            p.pos++
+            val, err := p.value()
+            if err != nil {
+                return nil, err
+            }
+            dict[canonicalLiteral(key)] = val
+        } else if len(dict) == 0 {
+            set = append(set, key)
+        } else {
+            return nil, fmt.Errorf("expected ':' at offset %d", p.pos)
+        }
+
+        p.skipSpace()
+        if p.pos < len(p.src) && p.src[p.pos] == ',' {
+            p.pos++
+        } else if p.pos >= len(p.src) || p.src[p.pos] != '}' {
+            return nil, fmt.Errorf("expected ',' or '}' at offset %d", p.pos)
+        }
+    }
+}
+
+func (p *literalParser) str() (interface{}, error) {
+    quote := p.src[p.pos]
+    p.pos++
+
+    var sb strings.Builder
+    for p.pos < len(p.src) {
+        ch := p.src[p.pos]
+        switch {
+        case ch == quote:
+            p.pos++
+            return sb.String(), nil
+        case ch == '\\' && p.pos+1 < len(p.src):
+            p.pos++
+            switch esc := p.src[p.pos]; esc {
+            case 'n':
+                sb.WriteByte('\n')
+            case 't':
+                sb.WriteByte('\t')
+            case 'r':
+                sb.WriteByte('\r')
+            case 'u', 'x':
+                width := 4
+                if esc == 'x' {
+                    width = 2
+                }
+                if p.pos+width >= len(p.src) {
+                    return nil, errors.New("truncated escape sequence")
+                }
+                code, err := strconv.ParseUint(p.src[p.pos+1:p.pos+1+width], 16, 32)
+                if err != nil {
+                    return nil, err
+                }
+                sb.WriteRune(rune(code))
+                p.pos += width
+            default:
+                sb.WriteByte(esc)
+            }
+            p.pos++
+        default:
+            sb.WriteByte(ch)
+            p.pos++
+        }
+    }
+    return nil, errors.New("unterminated string")
+}
+
+func (p *literalParser) atom() (interface{}, error) {
+    start := p.pos
+    for p.pos < len(p.src) && !strings.ContainsRune(",:]}) \t\r\n", rune(p.src[p.pos])) {
+        p.pos++
+    }
+    word := p.src[start:p.pos]
+
+    switch word {
+    case "True", "true":
+        return true, nil
+    case "False", "false":
+        return false, nil
+    case "None", "null":
+        return nil, nil
+    }
+
+    if f, err := strconv.ParseFloat(word, 64); err == nil {
+        return f, nil
+    }
+    return nil, fmt.Errorf("unrecognised token %q at offset %d", word, start)
+}
+
+// canonicalLiteral renders a parsed value deterministically, used for dict
+// keys and for ordering elements in unordered comparisons.
+func canonicalLiteral(v interface{}) string {
+    switch tv := v.(type) {
+    case nil:
+        return "null"
+    case bool:
+        return strconv.FormatBool(tv)
+    case float64:
+        return strconv.FormatFloat(tv, 'g', -1, 64)
+    case string:
+        return strconv.Quote(tv)
+    case []interface{}:
+        parts := make([]string, len(tv))
+        for i, item := range tv {
+            parts[i] = canonicalLiteral(item)
+        }
+        return "[" + strings.Join(parts, ",") + "]"
+    case literalDict:
+        keys := make([]string, 0, len(tv))
+        for k := range tv {
+            keys = append(keys, k)
+        }
+        sort.Strings(keys)
+        parts := make([]string, len(keys))
+        for i, k := range keys {
+            parts[i] = k + ":" + canonicalLiteral(tv[k])
+        }
+        return "{" + strings.Join(parts, ",") + "}"
+    default:
+        return fmt.Sprint(tv)
+    }
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareOutput(t *testing.T) {
+    tests := []struct {
+        name       string
+        comparator string
+        arg        string
+        expected   string
+        actual     string
+        want       bool
+    }{
+        {"exact match", CompareExact, "", "42", "42", true},
+        {"exact trims whitespace", "", "", " 42\n", "42  ", true},
+        {"exact mismatch", CompareExact, "", "42", "42.0", false},
+
+        {"float within default tolerance", CompareFloat, "", "0.3", "0.30000000000000004", true},
+        {"float outside default tolerance", CompareFloat, "", "0.3", "0.301", false},
+        {"float custom tolerance", CompareFloat, "0.01", "0.3", "0.305", true},
+        {"float inside lists", CompareFloat, "", "[1.0, 2.5]", "[1, 2.5000000001]", true},
+        {"float unparsable falls back to exact", CompareFloat, "", "abc", "abc", true},
+
+        {"unordered same elements", CompareUnordered, "", "[1, 2, 3]", "[3, 1, 2]", true},
+        {"unordered duplicates count", CompareUnordered, "", "[1, 1, 2]", "[1, 2, 2]", false},
+        {"unordered length differs", CompareUnordered, "", "[1, 2]", "[1, 2, 3]", false},
+        {"unordered nested lists", CompareUnordered, "", "[[1, 2], [3]]", "[[3], [1, 2]]", true},
+
+        {"structural quoting", CompareStructural, "", `['a', "b"]`, `["a", "b"]`, true},
+        {"structural dict order", CompareStructural, "", `{'x': 1, 'y': [True, None]}`, `{"y": [true, null], "x": 1}`, true},
+        {"structural tuple as list", CompareStructural, "", "(1, 2)", "[1, 2]", true},
+        {"structural list order matters", CompareStructural, "", "[1, 2]", "[2, 1]", false},
+        {"structural type differs", CompareStructural, "", "'1'", "1", false},
+
+        {"regex whole match", CompareRegex, "", `\d{4}-\d{2}`, "2024-01", true},
+        {"regex anchored", CompareRegex, "", `\d+`, "12a", false},
+        {"regex invalid pattern", CompareRegex, "", `(`, "(", false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            tc := TestCase{ExpectedOutput: tt.expected, Comparator: tt.comparator, CompareArg: tt.arg}
+            if got := CompareOutput(tc, tt.actual); got != tt.want {
+                t.Errorf("CompareOutput(%q, %q) = %v, want %v", tt.expected, tt.actual, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestParseLiteral(t *testing.T) {
+    tests := []struct {
+        in   string
+        want interface{}
+    }{
+        {"42", 42.0},
+        {"-1.5e3", -1500.0},
+        {"True", true},
+        {"false", false},
+        {"None", nil},
+        {"null", nil},
+        {`'it\'s'`, "it's"},
+        {`"tab\there"`, "tab\there"},
+        {`"é"`, "é"},
+        {"[1, 'a', [None]]", []interface{}{1.0, "a", []interface{}{nil}}},
+        {"(1,)", []interface{}{1.0}},
+        {"[]", []interface{}{}},
+        {"{3, 1, 2}", []interface{}{1.0, 2.0, 3.0}},
+        {"{'a': 1}", literalDict{`"a"`: 1.0}},
+        {"{}", literalDict{}},
+        {"  [ 1 ,2 ]  ", []interface{}{1.0, 2.0}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.in, func(t *testing.T) {
+            got, err := ParseLiteral(tt.in)
+            if err != nil {
+                t.Fatalf("ParseLiteral(%q) error: %v", tt.in, err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("ParseLiteral(%q) = %#v, want %#v", tt.in, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestParseLiteralErrors(t *testing.T) {
+    for _, in := range []string{"", "[1, 2", "'open", "{'a' 1}", "1 2", "nope", `"\u12"`} {
+        t.Run(in, func(t *testing.T) {
+            if v, err := ParseLiteral(in); err == nil {
+                t.Errorf("ParseLiteral(%q) = %#v, want error", in, v)
+            }
+        })
+    }
+}
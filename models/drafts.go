@@ -0,0 +1,110 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// ===================================================================================
+// DRAFT AUTOSAVE
+// ===================================================================================
+
+// DraftRetention is how long an untouched draft is kept, from
+// `draft_retention_days`.
+func DraftRetention() time.Duration {
+    return time.Duration(web.AppConfig.DefaultInt("draft_retention_days", 7)) * 24 * time.Hour
+}
+
+// ErrNoDraftOwner is returned when saving without an owner key, which would
+// otherwise share one draft between every keyless visitor.
+var ErrNoDraftOwner = errors.New("draft owner key is empty")
+
+// ErrTooManyDrafts is returned when a new draft would take an IP over
+// `draft_max_per_ip` live drafts. Every cookieless request gets a fresh
+// owner key, so without this one client could fill the table.
+var ErrTooManyDrafts = errors.New("too many drafts from this address")
+
+// GetDraft returns the owner's draft for a challenge, or nil if there is
+// none or it has expired.
+func GetDraft(owner string, challengeId int) *Draft {
+    if owner == "" {
+        return nil
+    }
+    o := orm.NewOrm()
+    d := &Draft{}
+    err := o.QueryTable("draft").
+        Filter("SessionId", owner).
+        Filter("Challenge__Id", challengeId).
+        Filter("Updated__gte", time.Now().Add(-DraftRetention())).
+        One(d)
+    if err != nil {
+        return nil
+    }
+    return d
+}
+
+// SaveDraft replaces the owner's draft for a challenge. ipHash is recorded
+// on new drafts and counted against `draft_max_per_ip`.
+func SaveDraft(owner, ipHash string, challengeId int, code string) (*Draft, error) {
+    if owner == "" {
+        return nil, ErrNoDraftOwner
+    }
+    o := orm.NewOrm()
+
+    d := &Draft{SessionId: owner, Challenge: &Challenge{Id: challengeId}, IpHash: ipHash}
+    err := o.QueryTable("draft").Filter("SessionId", owner).Filter("Challenge__Id", challengeId).One(d)
+    d.Code = code
+
+    switch err {
+    case nil:
+        _, err = o.Update(d, "Code", "Updated")
+    case orm.ErrNoRows:
+        live, countErr := o.QueryTable("draft").
+            Filter("IpHash", ipHash).
+            Filter("Updated__gte", time.Now().Add(-DraftRetention())).
+            Count()
+        if countErr != nil {
+            return nil, countErr
+        }
+        if live >= web.AppConfig.DefaultInt64("draft_max_per_ip", 100) {
+            return nil, ErrTooManyDrafts
+        }
+        _, err = o.Insert(d)
+    }
+    return d, err
+}
+
+// DeleteDraft discards the owner's draft, e.g. on reset to starter code.
+func DeleteDraft(owner string, challengeId int) error {
+    if owner == "" {
+        return nil
+    }
+    o := orm.NewOrm()
+    _, err := o.QueryTable("draft").Filter("SessionId", owner).Filter("Challenge__Id", challengeId).Delete()
+    return err
+}
+
+// PruneDrafts deletes every draft untouched for longer than DraftRetention.
+func PruneDrafts() (int64, error) {
+    o := orm.NewOrm()
+    return o.QueryTable("draft").Filter("Updated__lt", time.Now().Add(-DraftRetention())).Delete()
+}
+
+// StartDraftPruner runs PruneDrafts now and then every interval, so expired
+// drafts go away even when nobody is saving new ones.
+func StartDraftPruner(interval time.Duration) {
+    go func() {
+        for {
+            if n, err := PruneDrafts(); err != nil {
+                fmt.Println("Draft prune error:", err)
+            } else if n > 0 {
+                fmt.Printf("Pruned %d expired drafts\n", n)
+            }
+            time.Sleep(interval)
+        }
+    }()
+}
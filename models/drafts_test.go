@@ -0,0 +1,110 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/server/web"
+)
+
+func draftChallenge(t *testing.T) *Challenge {
+    t.Helper()
+    clearTables(t, "draft", "challenge")
+    c := &Challenge{Slug: "drafty", Title: "Drafty"}
+    if _, err := orm.NewOrm().Insert(c); err != nil {
+        t.Fatal(err)
+    }
+    return c
+}
+
+func TestSaveDraft(t *testing.T) {
+    c := draftChallenge(t)
+
+    if _, err := SaveDraft("", "ip", c.Id, "x"); err != ErrNoDraftOwner {
+        t.Errorf("keyless save: error = %v, want ErrNoDraftOwner", err)
+    }
+    if d := GetDraft("", c.Id); d != nil {
+        t.Error("keyless visitor got a draft")
+    }
+
+    if _, err := SaveDraft("alice", "ip", c.Id, "v1"); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := SaveDraft("alice", "ip", c.Id, "v2"); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := SaveDraft("bob", "ip", c.Id, "bob's"); err != nil {
+        t.Fatal(err)
+    }
+
+    if d := GetDraft("alice", c.Id); d == nil || d.Code != "v2" {
+        t.Errorf("alice's draft = %+v, want the latest save", d)
+    }
+    if d := GetDraft("bob", c.Id); d == nil || d.Code != "bob's" {
+        t.Errorf("bob's draft = %+v", d)
+    }
+    if n, _ := orm.NewOrm().QueryTable("draft").Count(); n != 2 {
+        t.Errorf("%d draft rows, want one per owner", n)
+    }
+
+    if err := DeleteDraft("alice", c.Id); err != nil {
+        t.Fatal(err)
+    }
+    if d := GetDraft("alice", c.Id); d != nil {
+        t.Error("draft still there after delete")
+    }
+    if d := GetDraft("bob", c.Id); d == nil {
+        t.Error("deleting one owner's draft removed another's")
+    }
+}
+
+func TestDraftExpiry(t *testing.T) {
+    c := draftChallenge(t)
+    o := orm.NewOrm()
+
+    if _, err := SaveDraft("old", "ip", c.Id, "stale"); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := SaveDraft("new", "ip", c.Id, "fresh"); err != nil {
+        t.Fatal(err)
+    }
+    // Updated is auto_now, so age the row behind the ORM's back
+    stale := time.Now().Add(-DraftRetention() - time.Hour)
+    if _, err := o.QueryTable("draft").Filter("SessionId", "old").Update(orm.Params{"updated": stale}); err != nil {
+        t.Fatal(err)
+    }
+
+    if d := GetDraft("old", c.Id); d != nil {
+        t.Error("expired draft returned")
+    }
+    if n, err := PruneDrafts(); err != nil || n != 1 {
+        t.Errorf("PruneDrafts = %d, %v; want 1 pruned", n, err)
+    }
+    if d := GetDraft("new", c.Id); d == nil {
+        t.Error("live draft pruned")
+    }
+}
+
+func TestDraftCapPerIP(t *testing.T) {
+    c := draftChallenge(t)
+    defer web.AppConfig.Set("draft_max_per_ip", "")
+    web.AppConfig.Set("draft_max_per_ip", "2")
+
+    for _, owner := range []string{"k1", "k2"} {
+        if _, err := SaveDraft(owner, "busy", c.Id, "x"); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if _, err := SaveDraft("k3", "busy", c.Id, "x"); err != ErrTooManyDrafts {
+        t.Errorf("third owner from one IP: error = %v, want ErrTooManyDrafts", err)
+    }
+
+    // Existing drafts can still be updated, and other IPs are unaffected
+    if _, err := SaveDraft("k1", "busy", c.Id, "edited"); err != nil {
+        t.Errorf("update at the cap: %v", err)
+    }
+    if _, err := SaveDraft("k3", "quiet", c.Id, "x"); err != nil {
+        t.Errorf("another IP: %v", err)
+    }
+}
@@ -0,0 +1,351 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+// ===================================================================================
+// CODE EXECUTION BACKENDS
+// ===================================================================================
+
+// ErrExecutorOffline is returned when the backend could not be reached at all,
+// as opposed to the submission itself failing.
+var ErrExecutorOffline = errors.New("execution engine offline")
+
+// Executor runs a single program and reports its output in Piston's shape.
+type Executor interface {
+    Execute(req PistonRequest) (PistonResponse, error)
+}
+
+// StreamingExecutor is implemented by backends that can report stdout while
+// the program is still running. onLine receives each complete line.
+type StreamingExecutor interface {
+    Executor
+    ExecuteStream(req PistonRequest, onLine func(string)) (PistonResponse, error)
+}
+
+// NewExecutor builds the backend selected by the `executor` key in app.conf.
+// Supported values are "piston" (default), "local" and "fake".
+func NewExecutor() Executor {
+    switch web.AppConfig.DefaultString("executor", "piston") {
+    case "local":
+        return NewLocalExecutor()
+    case "fake":
+        return NewFakeExecutor(nil)
+    default:
+        return NewPistonExecutor(
+            web.AppConfig.DefaultString("piston_url", "https://emkc.org/api/v2/piston/execute"),
+            time.Duration(web.AppConfig.DefaultInt("piston_throttle_ms", 250))*time.Millisecond,
+        )
+    }
+}
+
+// --- Piston (HTTP) ---
+
+// PistonExecutor posts to a Piston instance. The public emkc.org instance is
+// rate limited, so consecutive calls are spaced at least Throttle apart.
+type PistonExecutor struct {
+    URL      string
+    Throttle time.Duration
+    client   *http.Client
+
+    mu       sync.Mutex
+    lastCall time.Time
+}
+
+func NewPistonExecutor(url string, throttle time.Duration) *PistonExecutor {
+    return &PistonExecutor{
+        URL:      url,
+        Throttle: throttle,
+        client:   &http.Client{Timeout: 10 * time.Second},
+    }
+}
+
+func (p *PistonExecutor) Execute(req PistonRequest) (PistonResponse, error) {
+    var pistonResp PistonResponse
+
+    p.mu.Lock()
+    if wait := p.Throttle - time.Since(p.lastCall); wait > 0 {
+        time.Sleep(wait)
+    }
+    p.lastCall = time.Now()
+    p.mu.Unlock()
+
+    reqBody, err := json.Marshal(req)
+    if err != nil {
+        return pistonResp, err
+    }
+
+    r, err := http.NewRequest("POST", p.URL, bytes.NewBuffer(reqBody))
+    if err != nil {
+        return pistonResp, err
+    }
+    r.Header.Set("Content-Type", "application/json")
+
+    resp, err := p.client.Do(r)
+    if err != nil {
+        return pistonResp, fmt.Errorf("%w: %v", ErrExecutorOffline, err)
+    }
+    defer resp.Body.Close()
+
+    bodyBytes, _ := io.ReadAll(resp.Body)
+
+    if resp.StatusCode != 200 {
+        return pistonResp, fmt.Errorf("API Error: %s", string(bodyBytes))
+    }
+
+    if err := json.Unmarshal(bodyBytes, &pistonResp); err != nil {
+        return pistonResp, errors.New("error parsing execution response")
+    }
+
+    return pistonResp, nil
+}
+
+// --- Local Process Sandbox ---
+
+// LocalExecutor runs submissions as a subprocess on this host. Each run gets a
+// throwaway working directory, no network, rlimits on CPU time, address space
+// and file size, a wall-clock deadline and a cap on captured output.
+type LocalExecutor struct {
+    Commands   map[string]LocalCommand // Keyed by Piston runtime name
+    Timeout    time.Duration
+    CPUSeconds int
+    FileKB     int
+    MaxOutput  int
+}
+
+// LocalCommand is the interpreter invocation for one runtime. The executed
+// file names are appended to Argv.
+type LocalCommand struct {
+    Argv     []string
+    MemoryMB int
+}
+
+// Runtimes that work out of the box; others need `local_cmd_<language>` set.
+var defaultLocalCommands = map[string]string{
+    "python":     "python3 -I",
+    "javascript": "node",
+}
+
+func NewLocalExecutor() *LocalExecutor {
+    memoryMB := web.AppConfig.DefaultInt("local_memory_mb", 256)
+    commands := make(map[string]LocalCommand)
+
+    for _, lang := range GetLanguages() {
+        argv := strings.Fields(web.AppConfig.DefaultString("local_cmd_"+lang.Name, defaultLocalCommands[lang.Name]))
+        if len(argv) == 0 {
+            continue
+        }
+
+        // V8 reserves a large address range up front and aborts under a
+        // tight `ulimit -v`, so node gets a higher default ceiling.
+        langMemory := memoryMB
+        if lang.Name == "javascript" || lang.Name == "typescript" {
+            langMemory = 1024
+        }
+
+        commands[lang.Runtime] = LocalCommand{
+            Argv:     argv,
+            MemoryMB: web.AppConfig.DefaultInt("local_memory_mb_"+lang.Name, langMemory),
+        }
+    }
+
+    return &LocalExecutor{
+        Commands:   commands,
+        Timeout:    time.Duration(web.AppConfig.DefaultInt("local_timeout_ms", 10000)) * time.Millisecond,
+        CPUSeconds: web.AppConfig.DefaultInt("local_cpu_seconds", 5),
+        FileKB:     web.AppConfig.DefaultInt("local_file_kb", 1024),
+        MaxOutput:  web.AppConfig.DefaultInt("local_output_kb", 64) * 1024,
+    }
+}
+
+func (l *LocalExecutor) Execute(req PistonRequest) (PistonResponse, error) {
+    return l.ExecuteStream(req, nil)
+}
+
+func (l *LocalExecutor) ExecuteStream(req PistonRequest, onLine func(string)) (PistonResponse, error) {
+    var out PistonResponse
+
+    command, ok := l.Commands[req.Language]
+    if !ok {
+        return out, fmt.Errorf("local executor does not support %q", req.Language)
+    }
+    if len(req.Files) == 0 {
+        return out, errors.New("no files to execute")
+    }
+
+    attr, err := sandboxAttr()
+    if err != nil {
+        return out, fmt.Errorf("%w: %v", ErrExecutorOffline, err)
+    }
+
+    dir, err := os.MkdirTemp("", "run-")
+    if err != nil {
+        return out, fmt.Errorf("%w: %v", ErrExecutorOffline, err)
+    }
+    defer os.RemoveAll(dir)
+
+    for _, f := range req.Files {
+        if err := os.WriteFile(filepath.Join(dir, filepath.Base(f.Name)), []byte(f.Content), 0600); err != nil {
+            return out, err
+        }
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), l.Timeout)
+    defer cancel()
+
+    // dash only accepts one resource per ulimit call. The interpreter and its
+    // arguments arrive as $0/$@ so nothing user-controlled is interpolated.
+    limits := fmt.Sprintf("ulimit -t %d; ulimit -v %d; ulimit -f %d; exec \"$0\" \"$@\"",
+        l.CPUSeconds, command.MemoryMB*1024, l.FileKB)
+    args := append([]string{"-c", limits}, command.Argv...)
+    for _, f := range req.Files {
+        args = append(args, filepath.Base(f.Name))
+    }
+    args = append(args, req.Args...)
+
+    cmd := exec.CommandContext(ctx, "/bin/sh", args...)
+    cmd.Dir = dir
+    cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir, "TMPDIR=" + dir, "LANG=C.UTF-8"}
+    cmd.Stdin = bytes.NewBufferString(req.Stdin)
+    cmd.SysProcAttr = attr
+    cmd.Cancel = func() error { return killProcessGroup(cmd.Process.Pid) }
+    cmd.WaitDelay = time.Second
+
+    stdout := &cappedBuffer{limit: l.MaxOutput, onOverflow: cancel, onLine: onLine}
+    stderr := &cappedBuffer{limit: l.MaxOutput, onOverflow: cancel}
+    cmd.Stdout = stdout
+    cmd.Stderr = stderr
+
+    start := time.Now()
+    err = cmd.Run()
+    out.Run.WallTime = float64(time.Since(start).Microseconds()) / 1000
+    if err != nil {
+        var exitErr *exec.ExitError
+        if !errors.As(err, &exitErr) && ctx.Err() == nil {
+            return out, fmt.Errorf("%w: %v", ErrExecutorOffline, err)
+        }
+    }
+
+    switch {
+    case stdout.overflowed || stderr.overflowed:
+        stderr.note("Output limit exceeded.")
+    case ctx.Err() == context.DeadlineExceeded:
+        stderr.note("Execution timed out.")
+    case cmd.ProcessState != nil && cmd.ProcessState.ExitCode() == -1:
+        stderr.note("Process terminated: resource limit exceeded.")
+    }
+
+    out.Run.Stdout = stdout.String()
+    out.Run.Stderr = stderr.String()
+    out.Run.Code = -1
+    if cmd.ProcessState != nil {
+        out.Run.Code = cmd.ProcessState.ExitCode()
+        out.Run.CpuTime = float64((cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime()).Microseconds()) / 1000
+    }
+    return out, nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it and calls
+// onOverflow once when the child tries to write more. If onLine is set, each
+// complete line within the limit is also passed to it as it arrives.
+type cappedBuffer struct {
+    buf        bytes.Buffer
+    limit      int
+    overflowed bool
+    onOverflow func()
+    onLine     func(string)
+    lineStart  int
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+    n := len(p)
+    if room := c.limit - c.buf.Len(); room < len(p) {
+        if room > 0 {
+            c.buf.Write(p[:room])
+        }
+        if !c.overflowed {
+            c.overflowed = true
+            c.onOverflow()
+        }
+    } else {
+        c.buf.Write(p)
+    }
+
+    if c.onLine != nil {
+        for {
+            pending := c.buf.Bytes()[c.lineStart:]
+            i := bytes.IndexByte(pending, '\n')
+            if i < 0 {
+                break
+            }
+            c.onLine(string(pending[:i]))
+            c.lineStart += i + 1
+        }
+    }
+    return n, nil
+}
+
+func (c *cappedBuffer) note(msg string) {
+    if c.buf.Len() > 0 && !bytes.HasSuffix(c.buf.Bytes(), []byte("\n")) {
+        c.buf.WriteString("\n")
+    }
+    c.buf.WriteString(msg + "\n")
+}
+
+func (c *cappedBuffer) String() string {
+    return c.buf.String()
+}
+
+// --- In-Memory Fake ---
+
+// FakeExecutor never runs anything. Handler decides the response; every
+// request is recorded so callers can inspect what would have been executed.
+type FakeExecutor struct {
+    Handler func(PistonRequest) (PistonResponse, error)
+
+    mu       sync.Mutex
+    Requests []PistonRequest
+}
+
+func NewFakeExecutor(handler func(PistonRequest) (PistonResponse, error)) *FakeExecutor {
+    return &FakeExecutor{Handler: handler}
+}
+
+func (f *FakeExecutor) Execute(req PistonRequest) (PistonResponse, error) {
+    f.mu.Lock()
+    f.Requests = append(f.Requests, req)
+    f.mu.Unlock()
+
+    if f.Handler == nil {
+        return PistonResponse{}, nil
+    }
+    return f.Handler(req)
+}
+
+// ExecuteStream replays the handler's stdout line by line after the fact.
+func (f *FakeExecutor) ExecuteStream(req PistonRequest, onLine func(string)) (PistonResponse, error) {
+    resp, err := f.Execute(req)
+    if err == nil && onLine != nil {
+        for _, line := range strings.SplitAfter(resp.Run.Stdout, "\n") {
+            if strings.HasSuffix(line, "\n") {
+                onLine(strings.TrimSuffix(line, "\n"))
+            }
+        }
+    }
+    return resp, err
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+func TestNewExecutor(t *testing.T) {
+    defer web.AppConfig.Set("executor", "")
+
+    tests := []struct {
+        setting string
+        want    interface{}
+    }{
+        {"", &PistonExecutor{}},
+        {"piston", &PistonExecutor{}},
+        {"local", &LocalExecutor{}},
+        {"fake", &FakeExecutor{}},
+    }
+
+    for _, tt := range tests {
+        web.AppConfig.Set("executor", tt.setting)
+        if got := NewExecutor(); reflect.TypeOf(got) != reflect.TypeOf(tt.want) {
+            t.Errorf("executor = %q: got %T, want %T", tt.setting, got, tt.want)
+        }
+    }
+}
+
+func TestPistonExecutor(t *testing.T) {
+    req := PistonRequest{Language: "python", Version: "3.10.0", Files: []PistonFile{{Name: "main.py", Content: "print(1)"}}}
+
+    tests := []struct {
+        name    string
+        status  int
+        body    string
+        want    PistonRun
+        wantErr string
+    }{
+        {name: "ok", status: 200, body: `{"run":{"stdout":"1\n","code":0,"wall_time":12}}`, want: PistonRun{Stdout: "1\n", WallTime: 12}},
+        {name: "api error", status: 429, body: `requests are rate limited`, wantErr: "API Error: requests are rate limited"},
+        {name: "malformed body", status: 200, body: `not json`, wantErr: "error parsing execution response"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var got PistonRequest
+            server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                json.NewDecoder(r.Body).Decode(&got)
+                w.WriteHeader(tt.status)
+                w.Write([]byte(tt.body))
+            }))
+            defer server.Close()
+
+            resp, err := NewPistonExecutor(server.URL, 0).Execute(req)
+            if tt.wantErr != "" {
+                if err == nil || err.Error() != tt.wantErr {
+                    t.Fatalf("error = %v, want %q", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatal(err)
+            }
+            if resp.Run != tt.want {
+                t.Errorf("run = %+v, want %+v", resp.Run, tt.want)
+            }
+            if !reflect.DeepEqual(got, req) {
+                t.Errorf("posted %+v, want %+v", got, req)
+            }
+        })
+    }
+}
+
+func TestPistonExecutorOffline(t *testing.T) {
+    server := httptest.NewServer(http.NotFoundHandler())
+    url := server.URL
+    server.Close()
+
+    _, err := NewPistonExecutor(url, 0).Execute(PistonRequest{})
+    if !errors.Is(err, ErrExecutorOffline) {
+        t.Errorf("error = %v, want ErrExecutorOffline", err)
+    }
+}
+
+func TestPistonExecutorThrottle(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`{"run":{}}`))
+    }))
+    defer server.Close()
+
+    throttle := 50 * time.Millisecond
+    executor := NewPistonExecutor(server.URL, throttle)
+
+    start := time.Now()
+    for i := 0; i < 3; i++ {
+        if _, err := executor.Execute(PistonRequest{}); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if elapsed := time.Since(start); elapsed < 2*throttle {
+        t.Errorf("3 calls took %v, want at least %v", elapsed, 2*throttle)
+    }
+}
+
+func TestFakeExecutor(t *testing.T) {
+    empty := NewFakeExecutor(nil)
+    if resp, err := empty.Execute(PistonRequest{Language: "python"}); err != nil || resp.Run != (PistonRun{}) {
+        t.Errorf("nil handler: got %+v, %v", resp, err)
+    }
+
+    fake := NewFakeExecutor(func(req PistonRequest) (PistonResponse, error) {
+        return PistonResponse{Run: PistonRun{Stdout: "a\n\nb\npartial"}}, nil
+    })
+
+    var lines []string
+    resp, err := fake.ExecuteStream(PistonRequest{Language: "javascript"}, func(line string) {
+        lines = append(lines, line)
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := []string{"a", "", "b"}; !reflect.DeepEqual(lines, want) {
+        t.Errorf("streamed %q, want %q (incomplete last line dropped)", lines, want)
+    }
+    if resp.Run.Stdout != "a\n\nb\npartial" {
+        t.Errorf("stdout = %q", resp.Run.Stdout)
+    }
+    if len(fake.Requests) != 1 || fake.Requests[0].Language != "javascript" {
+        t.Errorf("recorded requests = %+v", fake.Requests)
+    }
+}
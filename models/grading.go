@@ -0,0 +1,229 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+// ===================================================================================
+// SUBMISSION GRADING
+// ===================================================================================
+
+// GradeReport is the outcome of grading one submission.
+type GradeReport struct {
+    Passed bool          // Every case passed
+    Score  float64       // Weighted percentage of cases passed, 0-100
+    Output string        // Console log; hidden cases only appear as a count
+    Cases  []CaseOutcome // Per-case detail, in test case order
+    WallMs float64       // Whole run as reported by the executor
+    CpuMs  float64
+
+    HintsUsed int // Hints revealed before submitting; already taken off Score
+}
+
+// ApplyHints lowers the score by penalty points for the hints revealed
+// before this attempt. Passing is unaffected; only the score pays for help.
+func (r *GradeReport) ApplyHints(used int, penalty float64) {
+    if used <= 0 {
+        return
+    }
+    r.HintsUsed = used
+    r.Score = math.Max(0, math.Round((r.Score-penalty)*10)/10)
+    r.Output += fmt.Sprintf("HINTS: %d used, -%g points\n", used, penalty)
+}
+
+// CaseOutcome is the stored result of one test case within a submission.
+type CaseOutcome struct {
+    TestCaseId int     `json:"test_case_id"`
+    Passed     bool    `json:"passed"`
+    Hidden     bool    `json:"hidden"`
+    Property   bool    `json:"property,omitempty"` // Randomly generated case
+    Input      string  `json:"input,omitempty"`    // Only stored for generated cases, which have no row
+    Output     string  `json:"output"`
+    Error      string  `json:"error,omitempty"`
+    Millis     float64 `json:"ms"`
+    TooSlow    bool    `json:"too_slow,omitempty"` // Right answer, over its time budget
+}
+
+// Public strips what a hidden case must not reveal to the submitter.
+func (o CaseOutcome) Public() CaseOutcome {
+    if o.Hidden {
+        return CaseOutcome{TestCaseId: o.TestCaseId, Passed: o.Passed, Hidden: true}
+    }
+    return o
+}
+
+// Test case classes. Performance cases exist to reject answers with the wrong
+// time complexity, so they always carry a time budget.
+const (
+    ClassCorrectness = "correctness"
+    ClassPerformance = "performance"
+)
+
+// Budget returns the time limit for one call of tc in milliseconds, or 0 for
+// none. Performance cases without their own budget use `perf_budget_ms`.
+func (tc TestCase) Budget() float64 {
+    if tc.BudgetMs > 0 {
+        return tc.BudgetMs
+    }
+    if tc.Class == ClassPerformance {
+        return web.AppConfig.DefaultFloat("perf_budget_ms", 1000)
+    }
+    return 0
+}
+
+// CheckTimedCases rejects time budgets for languages whose harness runs in
+// the submission's own process (everything but Python), where the
+// submission can rewrite the timer and its reported timings mean nothing.
+func CheckTimedCases(language string, cases []TestCase) error {
+    lang, err := LookupLanguage(language)
+    if err != nil || lang.stdinMarker {
+        return nil
+    }
+    for i, tc := range cases {
+        if tc.Class == ClassPerformance || tc.BudgetMs > 0 {
+            return fmt.Errorf("test %d: performance cases and time budgets are only supported for Python challenges", i+1)
+        }
+    }
+    return nil
+}
+
+// CasePassed grades a single harness result against its test case.
+func CasePassed(tc TestCase, res CaseResult) bool {
+    return res.Exception == "" && CompareOutput(tc, res.Output) && !overBudget(tc, res)
+}
+
+func overBudget(tc TestCase, res CaseResult) bool {
+    budget := tc.Budget()
+    return budget > 0 && res.Millis > budget
+}
+
+// GradeCases compares harness results against each test case's expected
+// output and renders the PASS/FAIL log shown in the challenge console.
+// run.Stderr is whatever the program printed outside the harness, e.g. a
+// crash that stopped later cases from reporting.
+func GradeCases(cases []TestCase, results map[int]CaseResult, run PistonRun) GradeReport {
+    report := GradeReport{Passed: true}
+    var outputLog strings.Builder
+    var totalWeight, passedWeight float64
+    hiddenTotal, hiddenPassed := 0, 0
+    propertyTotal, propertyPassed := 0, 0
+
+    for i, tc := range cases {
+        weight := tc.Weight
+        if weight <= 0 {
+            weight = 1
+        }
+        totalWeight += weight
+
+        res, ok := results[i]
+        actualOutput := strings.TrimSpace(res.Output)
+        passed := ok && CasePassed(tc, res)
+        tooSlow := ok && res.Exception == "" && overBudget(tc, res) && CompareOutput(tc, res.Output)
+
+        if passed {
+            passedWeight += weight
+        } else {
+            report.Passed = false
+        }
+
+        outcome := CaseOutcome{
+            TestCaseId: tc.Id,
+            Passed:     passed,
+            Hidden:     tc.Hidden,
+            Property:   tc.Property,
+            Output:     actualOutput,
+            Error:      res.Exception,
+            Millis:     res.Millis,
+            TooSlow:    tooSlow,
+        }
+        if tc.Property {
+            outcome.Input = tc.InputArgs
+        }
+        report.Cases = append(report.Cases, outcome)
+
+        if tc.Property {
+            propertyTotal++
+            if passed {
+                propertyPassed++
+            }
+            continue
+        }
+
+        if tc.Hidden {
+            hiddenTotal++
+            if passed {
+                hiddenPassed++
+            }
+            continue
+        }
+
+        label := "Input(" + tc.InputArgs + ")"
+        if tc.Class == ClassPerformance {
+            // Performance inputs are large; don't echo them
+            label = "PERFORMANCE case " + fmt.Sprint(i+1)
+        }
+
+        switch {
+        case !ok:
+            outputLog.WriteString(fmt.Sprintf("✗ FAIL: %s\n  No result reported.\n", label))
+        case res.Exception != "":
+            outputLog.WriteString(fmt.Sprintf("ERROR on %s:\n%s\n", label, res.Exception))
+        case tooSlow:
+            outputLog.WriteString(fmt.Sprintf("✗ TOO SLOW: %s [%.1fms, budget %.0fms]\n", label, res.Millis, tc.Budget()))
+        case passed && tc.Class == ClassPerformance:
+            outputLog.WriteString(fmt.Sprintf("✓ PASS: %s [%.1fms, budget %.0fms]\n", label, res.Millis, tc.Budget()))
+        case passed:
+            outputLog.WriteString(fmt.Sprintf("✓ PASS: %s -> Output(%s) [%.1fms]\n", label, actualOutput, res.Millis))
+        case tc.Class == ClassPerformance:
+            outputLog.WriteString(fmt.Sprintf("✗ FAIL: %s\n  Wrong answer.\n", label))
+        default:
+            expected := strings.TrimSpace(tc.ExpectedOutput)
+            if tc.Comparator != "" && tc.Comparator != CompareExact {
+                expected += " (" + tc.Comparator + ")"
+            }
+            outputLog.WriteString(fmt.Sprintf("✗ FAIL: %s [%.1fms]\n  Expected: %s\n  Got:      %s\n", label, res.Millis, expected, actualOutput))
+        }
+    }
+
+    if hiddenTotal > 0 {
+        mark := "✓"
+        if hiddenPassed < hiddenTotal {
+            mark = "✗"
+        }
+        outputLog.WriteString(fmt.Sprintf("%s HIDDEN: %d/%d passed\n", mark, hiddenPassed, hiddenTotal))
+    }
+
+    if propertyTotal > 0 {
+        if i := shortestFailure(cases, func(i int) bool { return !report.Cases[i].Passed }); i >= 0 {
+            tc, outcome := cases[i], report.Cases[i]
+            outputLog.WriteString(fmt.Sprintf("✗ RANDOM: %d/%d generated inputs passed\n  Smallest failing input: (%s)\n", propertyPassed, propertyTotal, tc.InputArgs))
+            if outcome.Error != "" {
+                outputLog.WriteString(outcome.Error + "\n")
+            } else {
+                outputLog.WriteString(fmt.Sprintf("  Expected: %s\n  Got:      %s\n", tc.ExpectedOutput, outcome.Output))
+            }
+        } else {
+            outputLog.WriteString(fmt.Sprintf("✓ RANDOM: %d/%d generated inputs passed\n", propertyPassed, propertyTotal))
+        }
+    }
+
+    if strings.TrimSpace(run.Stderr) != "" && len(results) < len(cases) {
+        cleanErr := strings.Replace(run.Stderr, "/piston/jobs/", "", -1)
+        outputLog.WriteString(fmt.Sprintf("STDERR:\n%s\n", cleanErr))
+    }
+
+    report.WallMs, report.CpuMs = run.WallTime, run.CpuTime
+    if run.WallTime > 0 {
+        outputLog.WriteString(fmt.Sprintf("RUNTIME: %.0fms wall, %.0fms CPU\n", run.WallTime, run.CpuTime))
+    }
+
+    if totalWeight > 0 {
+        report.Score = math.Round(passedWeight/totalWeight*1000) / 10
+    }
+    report.Output = outputLog.String()
+    return report
+}
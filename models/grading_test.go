@@ -0,0 +1,231 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+func TestGradeCases(t *testing.T) {
+    tests := []struct {
+        name       string
+        cases      []TestCase
+        results    map[int]CaseResult
+        run        PistonRun
+        wantPassed bool
+        wantScore  float64
+        wantLog    []string // Substrings the console log must contain
+        hideLog    []string // Substrings it must not contain
+    }{
+        {
+            name:       "all pass",
+            cases:      []TestCase{{InputArgs: "1", ExpectedOutput: "2"}, {InputArgs: "2", ExpectedOutput: "4"}},
+            results:    map[int]CaseResult{0: {Output: "2", Millis: 1}, 1: {Output: "4", Millis: 1}},
+            wantPassed: true,
+            wantScore:  100,
+            wantLog:    []string{"✓ PASS: Input(1) -> Output(2)", "✓ PASS: Input(2) -> Output(4)"},
+        },
+        {
+            name:       "weighted partial",
+            cases:      []TestCase{{InputArgs: "1", ExpectedOutput: "2", Weight: 3}, {InputArgs: "2", ExpectedOutput: "4"}},
+            results:    map[int]CaseResult{0: {Output: "2"}, 1: {Output: "5"}},
+            wantPassed: false,
+            wantScore:  75,
+            wantLog:    []string{"✗ FAIL: Input(2)", "Expected: 4", "Got:      5"},
+        },
+        {
+            name:       "exception and missing result",
+            cases:      []TestCase{{InputArgs: "1", ExpectedOutput: "2"}, {InputArgs: "2", ExpectedOutput: "4"}},
+            results:    map[int]CaseResult{0: {Exception: "ZeroDivisionError"}},
+            run:        PistonRun{Stderr: "Killed"},
+            wantPassed: false,
+            wantScore:  0,
+            wantLog:    []string{"ERROR on Input(1):\nZeroDivisionError", "No result reported.", "STDERR:\nKilled"},
+        },
+        {
+            name: "hidden cases are only counted",
+            cases: []TestCase{
+                {InputArgs: "1", ExpectedOutput: "2"},
+                {InputArgs: "secret", ExpectedOutput: "x", Hidden: true},
+                {InputArgs: "secret2", ExpectedOutput: "y", Hidden: true},
+            },
+            results:    map[int]CaseResult{0: {Output: "2"}, 1: {Output: "x"}, 2: {Output: "nope"}},
+            wantPassed: false,
+            wantScore:  66.7,
+            wantLog:    []string{"✗ HIDDEN: 1/2 passed"},
+            hideLog:    []string{"secret", "nope"},
+        },
+        {
+            name:       "right answer over budget",
+            cases:      []TestCase{{InputArgs: "big", ExpectedOutput: "1", Class: ClassPerformance, BudgetMs: 50}},
+            results:    map[int]CaseResult{0: {Output: "1", Millis: 80}},
+            wantPassed: false,
+            wantScore:  0,
+            wantLog:    []string{"✗ TOO SLOW: PERFORMANCE case 1 [80.0ms, budget 50ms]"},
+            hideLog:    []string{"big"},
+        },
+        {
+            name:       "performance within budget",
+            cases:      []TestCase{{InputArgs: "big", ExpectedOutput: "1", Class: ClassPerformance, BudgetMs: 50}},
+            results:    map[int]CaseResult{0: {Output: "1", Millis: 20}},
+            wantPassed: true,
+            wantScore:  100,
+            wantLog:    []string{"✓ PASS: PERFORMANCE case 1 [20.0ms, budget 50ms]"},
+        },
+        {
+            name: "smallest failing generated input",
+            cases: []TestCase{
+                {InputArgs: "[1, 2, 3]", ExpectedOutput: "6", Property: true},
+                {InputArgs: "[10, 20]", ExpectedOutput: "30", Property: true},
+                {InputArgs: "[1]", ExpectedOutput: "1", Property: true},
+            },
+            results:    map[int]CaseResult{0: {Output: "0"}, 1: {Output: "0"}, 2: {Output: "1"}},
+            wantPassed: false,
+            wantScore:  33.3,
+            wantLog:    []string{"✗ RANDOM: 1/3 generated inputs passed", "Smallest failing input: ([10, 20])", "Expected: 30"},
+        },
+        {
+            name:       "runtime line",
+            cases:      []TestCase{{InputArgs: "1", ExpectedOutput: "1"}},
+            results:    map[int]CaseResult{0: {Output: "1"}},
+            run:        PistonRun{WallTime: 120, CpuTime: 80},
+            wantPassed: true,
+            wantScore:  100,
+            wantLog:    []string{"RUNTIME: 120ms wall, 80ms CPU"},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            report := GradeCases(tt.cases, tt.results, tt.run)
+            if report.Passed != tt.wantPassed {
+                t.Errorf("Passed = %v, want %v", report.Passed, tt.wantPassed)
+            }
+            if report.Score != tt.wantScore {
+                t.Errorf("Score = %v, want %v", report.Score, tt.wantScore)
+            }
+            if len(report.Cases) != len(tt.cases) {
+                t.Errorf("got %d case outcomes, want %d", len(report.Cases), len(tt.cases))
+            }
+            for _, s := range tt.wantLog {
+                if !strings.Contains(report.Output, s) {
+                    t.Errorf("log missing %q:\n%s", s, report.Output)
+                }
+            }
+            for _, s := range tt.hideLog {
+                if strings.Contains(report.Output, s) {
+                    t.Errorf("log reveals %q:\n%s", s, report.Output)
+                }
+            }
+        })
+    }
+}
+
+func TestApplyHints(t *testing.T) {
+    tests := []struct {
+        score, penalty float64
+        used           int
+        want           float64
+    }{
+        {100, 10, 0, 100},
+        {100, 10, 1, 90},
+        {100, 25.5, 2, 74.5},
+        {5, 10, 1, 0},
+        {66.7, 0.04, 1, 66.7}, // Rounded to one decimal
+    }
+
+    for _, tt := range tests {
+        t.Run(fmt.Sprintf("%g minus %d x %g", tt.score, tt.used, tt.penalty), func(t *testing.T) {
+            report := GradeReport{Passed: true, Score: tt.score}
+            report.ApplyHints(tt.used, tt.penalty)
+            if report.Score != tt.want {
+                t.Errorf("Score = %v, want %v", report.Score, tt.want)
+            }
+            if !report.Passed {
+                t.Error("hints must not fail a passing report")
+            }
+            if report.HintsUsed != tt.used {
+                t.Errorf("HintsUsed = %d, want %d", report.HintsUsed, tt.used)
+            }
+        })
+    }
+}
+
+func TestHintPenalty(t *testing.T) {
+    clearTables(t, "hint", "challenge")
+    c := Challenge{Slug: "hinted", Title: "Hinted"}
+    o := orm.NewOrm()
+    if _, err := o.Insert(&c); err != nil {
+        t.Fatal(err)
+    }
+    // Stored out of order: penalties follow Position, not insertion
+    for _, h := range []Hint{{Position: 3, Penalty: 20}, {Position: 1, Penalty: 5}, {Position: 2, Penalty: 10}} {
+        h.Challenge = &c
+        if _, err := o.Insert(&h); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    tests := []struct {
+        used int
+        want float64
+    }{
+        {0, 0},
+        {1, 5},
+        {2, 15},
+        {3, 35},
+        {9, 35},
+    }
+
+    for _, tt := range tests {
+        if got := HintPenalty(c.Id, tt.used); got != tt.want {
+            t.Errorf("HintPenalty(%d) = %v, want %v", tt.used, got, tt.want)
+        }
+    }
+}
+
+func TestCheckTimedCases(t *testing.T) {
+    timed := []TestCase{{InputArgs: "1"}, {InputArgs: "big", Class: ClassPerformance}}
+    budgeted := []TestCase{{InputArgs: "1", BudgetMs: 10}}
+    plain := []TestCase{{InputArgs: "1", Class: ClassCorrectness}}
+
+    tests := []struct {
+        language string
+        cases    []TestCase
+        wantErr  string
+    }{
+        {"python", timed, ""},
+        {"", budgeted, ""}, // Defaults to python
+        {"javascript", timed, "test 2: performance cases"},
+        {"typescript", budgeted, "test 1:"},
+        {"go", timed, "only supported for Python"},
+        {"go", plain, ""},
+    }
+
+    for _, tt := range tests {
+        err := CheckTimedCases(tt.language, tt.cases)
+        if tt.wantErr == "" {
+            if err != nil {
+                t.Errorf("%q: %v", tt.language, err)
+            }
+            continue
+        }
+        if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+            t.Errorf("%q: error = %v, want %q", tt.language, err, tt.wantErr)
+        }
+    }
+}
+
+func TestCaseOutcomePublic(t *testing.T) {
+    hidden := CaseOutcome{TestCaseId: 7, Passed: false, Hidden: true, Output: "leak", Error: "trace", Millis: 3}
+    if got, want := hidden.Public(), (CaseOutcome{TestCaseId: 7, Hidden: true}); got != want {
+        t.Errorf("hidden Public() = %+v, want %+v", got, want)
+    }
+
+    visible := CaseOutcome{TestCaseId: 8, Passed: true, Output: "3", Millis: 1}
+    if got := visible.Public(); got != visible {
+        t.Errorf("visible Public() = %+v, want it unchanged", got)
+    }
+}
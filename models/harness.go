@@ -0,0 +1,105 @@
+package models
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ===================================================================================
+// TEST HARNESS GENERATION
+// ===================================================================================
+
+// CaseResult is what the harness reports for a single test case.
+type CaseResult struct {
+    Index     int     `json:"index"`
+    Output    string  `json:"output"`
+    Exception string  `json:"exception"`
+    Millis    float64 `json:"ms"`
+}
+
+// HarnessRun is one execution that evaluates every test case of a challenge.
+// Results are written to stdout as JSON lines prefixed with a per-run marker,
+// so stray prints from the submission can't be mistaken for results.
+type HarnessRun struct {
+    Request PistonRequest
+    marker  string
+}
+
+// NewHarnessRun wraps userCode in a program that calls funcName once per test
+// case, using the harness template registered for lang. Each case is isolated
+// so exceptions (and, where the language allows, state) can't leak between
+// cases.
+func NewHarnessRun(lang Language, userCode, funcName string, cases []TestCase) (HarnessRun, error) {
+    if !identifierRe.MatchString(funcName) {
+        return HarnessRun{}, fmt.Errorf("invalid function name %q", funcName)
+    }
+
+    nonce := make([]byte, 8)
+    rand.Read(nonce)
+    marker := "@@CASE_" + hex.EncodeToString(nonce) + "@@"
+
+    args := make([]string, len(cases))
+    for i, tc := range cases {
+        args[i] = tc.InputArgs
+    }
+
+    run := HarnessRun{
+        Request: PistonRequest{
+            Language: lang.Runtime,
+            Version:  lang.Version,
+            Files:    lang.harness(userCode, funcName, marker, args),
+        },
+        marker: marker,
+    }
+    if lang.stdinMarker {
+        run.Request.Stdin = marker + "\n"
+    }
+    return run, nil
+}
+
+// Results extracts the per-case results from the program's stdout, keyed by
+// test case index. A case that reported twice is treated as tampering.
+func (h HarnessRun) Results(stdout string) (map[int]CaseResult, error) {
+    results := make(map[int]CaseResult)
+
+    scanner := bufio.NewScanner(strings.NewReader(stdout))
+    scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+    for scanner.Scan() {
+        res, ok, err := h.ParseLine(scanner.Text())
+        if err != nil {
+            return results, err
+        }
+        if !ok {
+            continue
+        }
+        if _, dup := results[res.Index]; dup {
+            return results, fmt.Errorf("duplicate result for case %d", res.Index+1)
+        }
+        results[res.Index] = res
+    }
+
+    return results, scanner.Err()
+}
+
+// ParseLine decodes a single stdout line. ok is false for lines that aren't
+// harness results, such as the submission's own output.
+func (h HarnessRun) ParseLine(line string) (res CaseResult, ok bool, err error) {
+    if !strings.HasPrefix(line, h.marker) {
+        return res, false, nil
+    }
+    if err := json.Unmarshal([]byte(strings.TrimPrefix(line, h.marker)), &res); err != nil {
+        return res, false, fmt.Errorf("malformed harness output: %v", err)
+    }
+    return res, true, nil
+}
+
+// jsonLiteral encodes v as JSON, which doubles as a valid Python or
+// JavaScript literal for the strings and string lists the harnesses embed.
+func jsonLiteral(v interface{}) string {
+    b, _ := json.Marshal(v)
+    return string(b)
+}
@@ -0,0 +1,232 @@
+package models
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pythonExecutor is a FakeExecutor that runs python requests with the host's
+// python3, unsandboxed, so harness programs can be tested end to end.
+func pythonExecutor(t *testing.T) *FakeExecutor {
+    t.Helper()
+    python, err := exec.LookPath("python3")
+    if err != nil {
+        t.Skip("python3 not installed")
+    }
+
+    return NewFakeExecutor(func(req PistonRequest) (PistonResponse, error) {
+        var resp PistonResponse
+        dir := t.TempDir()
+        for _, f := range req.Files {
+            if err := os.WriteFile(filepath.Join(dir, f.Name), []byte(f.Content), 0600); err != nil {
+                return resp, err
+            }
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+        defer cancel()
+
+        cmd := exec.CommandContext(ctx, python, "-I", req.Files[0].Name)
+        cmd.Dir = dir
+        cmd.Stdin = strings.NewReader(req.Stdin)
+        var stdout, stderr strings.Builder
+        cmd.Stdout, cmd.Stderr = &stdout, &stderr
+        err := cmd.Run()
+        if _, exited := err.(*exec.ExitError); err != nil && !exited {
+            return resp, err
+        }
+
+        resp.Run = PistonRun{Stdout: stdout.String(), Stderr: stderr.String(), Code: cmd.ProcessState.ExitCode()}
+        return resp, nil
+    })
+}
+
+func TestHarnessResults(t *testing.T) {
+    lang, _ := LookupLanguage("python")
+    run, err := NewHarnessRun(lang, "def add(a, b):\n    return a + b\n", "add", []TestCase{{InputArgs: "1, 2"}, {InputArgs: "2, 2"}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    m := run.marker
+
+    tests := []struct {
+        name    string
+        stdout  string
+        want    map[int]string // Output per reported case
+        wantErr string
+    }{
+        {
+            name:   "results among other output",
+            stdout: "debug\n" + m + `{"index":0,"output":"3","ms":1}` + "\nmore\n" + m + `{"index":1,"output":"4","ms":1}` + "\n",
+            want:   map[int]string{0: "3", 1: "4"},
+        },
+        {
+            name:   "lines with another marker are ignored",
+            stdout: `@@CASE_0000000000000000@@{"index":1,"output":"4"}` + "\n" + m + `{"index":0,"output":"3"}` + "\n",
+            want:   map[int]string{0: "3"},
+        },
+        {
+            name:    "duplicate result",
+            stdout:  m + `{"index":0,"output":"3"}` + "\n" + m + `{"index":0,"output":"3"}` + "\n",
+            wantErr: "duplicate result for case 1",
+        },
+        {
+            name:    "malformed result",
+            stdout:  m + "{not json\n",
+            wantErr: "malformed harness output",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            results, err := run.Results(tt.stdout)
+            if tt.wantErr != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                    t.Fatalf("error = %v, want %q", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatal(err)
+            }
+            if len(results) != len(tt.want) {
+                t.Errorf("got %d results, want %d", len(results), len(tt.want))
+            }
+            for i, out := range tt.want {
+                if results[i].Output != out {
+                    t.Errorf("case %d output = %q, want %q", i, results[i].Output, out)
+                }
+            }
+        })
+    }
+}
+
+func TestNewHarnessRunRejectsBadFunctionName(t *testing.T) {
+    lang, _ := LookupLanguage("python")
+    for _, name := range []string{"", "1abc", "f()", "a b", "os.system"} {
+        if _, err := NewHarnessRun(lang, "", name, nil); err == nil {
+            t.Errorf("NewHarnessRun accepted function name %q", name)
+        }
+    }
+}
+
+// TestPythonHarness runs real submissions through the Python harness. The
+// marker only ever reaches the parent process, over stdin.
+func TestPythonHarness(t *testing.T) {
+    executor := pythonExecutor(t)
+    lang, _ := LookupLanguage("python")
+
+    type want struct {
+        output    string
+        exception string // Substring; "" means none
+    }
+    tests := []struct {
+        name  string
+        code  string
+        cases []string
+        want  []want
+    }{
+        {
+            name:  "one call per case",
+            code:  "print('module level output is dropped')\ndef solve(a, b):\n    return a + b\n",
+            cases: []string{"1, 2", "'a', 'b'", "[1], [2]"},
+            want:  []want{{output: "3"}, {output: "ab"}, {output: "[1, 2]"}},
+        },
+        {
+            name:  "state does not leak between cases",
+            code:  "calls = []\ndef solve(x):\n    calls.append(x)\n    return len(calls)\n",
+            cases: []string{"1", "2"},
+            want:  []want{{output: "1"}, {output: "1"}},
+        },
+        {
+            name:  "exceptions only show the submission's frames",
+            code:  "def solve(x):\n    return 1 / x\n",
+            cases: []string{"0", "4"},
+            want:  []want{{exception: "ZeroDivisionError"}, {output: "0.25"}},
+        },
+        {
+            name:  "missing function",
+            code:  "def other():\n    pass\n",
+            cases: []string{"1"},
+            want:  []want{{exception: "function 'solve' is not defined"}},
+        },
+        {
+            name:  "syntax error",
+            code:  "def solve(x)\n    return x\n",
+            cases: []string{"1"},
+            want:  []want{{exception: "SyntaxError"}},
+        },
+        {
+            name:  "exiting only loses the current case",
+            code:  "import sys, os\ndef solve(x):\n    if x == 1:\n        sys.exit(0)\n    if x == 2:\n        os._exit(0)\n    return x\n",
+            cases: []string{"1", "2", "3"},
+            want:  []want{{exception: "SystemExit"}, {exception: "process exited"}, {output: "3"}},
+        },
+        {
+            name: "forged result lines are caught",
+            code: "import sys\ndef solve(x):\n" +
+                "    sys.__stdout__.write('{\"id\": \"guess\", \"output\": \"42\"}\\n')\n" +
+                "    sys.__stdout__.flush()\n" +
+                "    return x\n",
+            cases: []string{"1", "2"},
+            want:  []want{{exception: "output channel"}, {exception: "output channel"}},
+        },
+        {
+            name:  "the marker is not reachable from the submission",
+            code:  "import sys\ndef solve():\n    return sys.stdin.readline() + str(sys.argv)\n",
+            cases: []string{""},
+            want:  []want{{output: "['-c']"}},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            cases := make([]TestCase, len(tt.cases))
+            for i, a := range tt.cases {
+                cases[i] = TestCase{InputArgs: a}
+            }
+            run, err := NewHarnessRun(lang, tt.code, "solve", cases)
+            if err != nil {
+                t.Fatal(err)
+            }
+            for _, f := range run.Request.Files {
+                if strings.Contains(f.Content, run.marker) {
+                    t.Fatalf("marker embedded in %s", f.Name)
+                }
+            }
+
+            resp, err := executor.Execute(run.Request)
+            if err != nil {
+                t.Fatal(err)
+            }
+            results, err := run.Results(resp.Run.Stdout)
+            if err != nil {
+                t.Fatalf("%v\nstderr: %s", err, resp.Run.Stderr)
+            }
+            if len(results) != len(tt.want) {
+                t.Fatalf("got %d results, want %d\nstdout: %s\nstderr: %s", len(results), len(tt.want), resp.Run.Stdout, resp.Run.Stderr)
+            }
+
+            for i, w := range tt.want {
+                res := results[i]
+                if w.exception == "" && res.Exception != "" {
+                    t.Errorf("case %d raised %q", i, res.Exception)
+                }
+                if !strings.Contains(res.Exception, w.exception) {
+                    t.Errorf("case %d exception = %q, want %q", i, res.Exception, w.exception)
+                }
+                if strings.Contains(res.Exception, "main.py") || strings.Contains(res.Exception, "<string>") {
+                    t.Errorf("case %d exception shows harness frames: %q", i, res.Exception)
+                }
+                if got := strings.TrimSpace(res.Output); got != w.output {
+                    t.Errorf("case %d output = %q, want %q", i, got, w.output)
+                }
+            }
+        })
+    }
+}
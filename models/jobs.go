@@ -0,0 +1,378 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ===================================================================================
+// ASYNCHRONOUS RUN QUEUE
+// ===================================================================================
+
+// Job states reported to pollers and event streams.
+const (
+    JobQueued  = "queued"
+    JobRunning = "running"
+    JobDone    = "done"
+    JobFailed  = "failed"
+)
+
+// ErrQueueFull is returned by Submit when the global cap on queued plus
+// running jobs has been reached.
+var ErrQueueFull = errors.New("execution queue is full")
+
+// How long finished jobs stay available to pollers.
+const jobRetention = 10 * time.Minute
+
+// JobEvent is one update on a job: a status change, a finished test case, or
+// the final result. Events are replayed to late subscribers.
+type JobEvent struct {
+    Type   string                 `json:"type"` // "status", "case" or "done"
+    Status string                 `json:"status,omitempty"`
+    Case   *CaseProgress          `json:"case,omitempty"`
+    Result map[string]interface{} `json:"result,omitempty"`
+}
+
+// CaseProgress reports one finished test case. Hidden and performance cases
+// carry no input.
+type CaseProgress struct {
+    Index    int     `json:"index"`
+    Passed   bool    `json:"passed"`
+    Hidden   bool    `json:"hidden"`
+    Property bool    `json:"property,omitempty"`
+    Input    string  `json:"input,omitempty"`
+    Millis   float64 `json:"ms"`
+    BudgetMs float64 `json:"budget_ms,omitempty"` // Set for performance cases
+}
+
+// RunJob is a queued submission plus everything needed to grade and store it.
+type RunJob struct {
+    ID        string
+    Challenge Challenge
+    Cases     []TestCase
+    Run       HarnessRun
+    UserCode  string
+    IpHash    string
+    UserAgent string
+    Created   time.Time
+
+    // Set before Submit when hints were revealed for this challenge
+    HintsUsed   int
+    HintPenalty float64
+    Handle      *Handle // Leaderboard handle, if the visitor claimed one
+
+    mu       sync.Mutex
+    total    int // Fixed cases plus the random cases still to be generated
+    status   string
+    events   []JobEvent
+    progress []CaseProgress
+    result   map[string]interface{}
+    finished time.Time
+    subs     []chan JobEvent
+}
+
+func NewRunJob(challenge Challenge, cases []TestCase, run HarnessRun, userCode, ip, userAgent string) *RunJob {
+    id := make([]byte, 8)
+    rand.Read(id)
+
+    return &RunJob{
+        ID:        hex.EncodeToString(id),
+        Challenge: challenge,
+        Cases:     cases,
+        Run:       run,
+        UserCode:  userCode,
+        IpHash:    HashIP(ip),
+        UserAgent: userAgent,
+        Created:   time.Now(),
+        total:     len(cases) + challenge.PropertyCount(),
+        status:    JobQueued,
+    }
+}
+
+// Snapshot is the polling view of a job.
+func (j *RunJob) Snapshot() map[string]interface{} {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+
+    return map[string]interface{}{
+        "id":     j.ID,
+        "status": j.status,
+        "total":  j.total,
+        "cases":  append([]CaseProgress{}, j.progress...),
+        "result": j.result,
+    }
+}
+
+// Subscribe returns a channel that first replays every event so far, then
+// receives new ones, and is closed after the final "done" event. The channel
+// is sized so publishing never blocks: a job emits at most one event per
+// case plus a handful of status events.
+func (j *RunJob) Subscribe() (<-chan JobEvent, func()) {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+
+    ch := make(chan JobEvent, j.total+8)
+    for _, ev := range j.events {
+        ch <- ev
+    }
+
+    if !j.finished.IsZero() {
+        close(ch)
+        return ch, func() {}
+    }
+
+    j.subs = append(j.subs, ch)
+    return ch, func() {
+        j.mu.Lock()
+        defer j.mu.Unlock()
+        for i, sub := range j.subs {
+            if sub == ch {
+                j.subs = append(j.subs[:i], j.subs[i+1:]...)
+                break
+            }
+        }
+    }
+}
+
+func (j *RunJob) publish(ev JobEvent) {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+
+    switch ev.Type {
+    case "status":
+        j.status = ev.Status
+    case "case":
+        j.progress = append(j.progress, *ev.Case)
+    case "done":
+        j.status = ev.Status
+        j.result = ev.Result
+        j.finished = time.Now()
+    }
+
+    j.events = append(j.events, ev)
+    for _, sub := range j.subs {
+        select {
+        case sub <- ev:
+        default:
+        }
+        if ev.Type == "done" {
+            close(sub)
+        }
+    }
+    if ev.Type == "done" {
+        j.subs = nil
+    }
+}
+
+// addPropertyCases generates the job's random cases and rebuilds the harness
+// run so the submission is checked against them too.
+func (j *RunJob) addPropertyCases(executor Executor) error {
+    generated, err := GeneratePropertyCases(executor, j.Challenge, j.Cases)
+    if err != nil || len(generated) == 0 {
+        return err
+    }
+
+    lang, err := LookupLanguage(j.Challenge.Language)
+    if err != nil {
+        return err
+    }
+    cases := append(append([]TestCase{}, j.Cases...), generated...)
+    run, err := NewHarnessRun(lang, j.UserCode, j.Challenge.funcName(), cases)
+    if err != nil {
+        return err
+    }
+
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    j.Cases = cases
+    j.Run = run
+    j.total = len(cases)
+    return nil
+}
+
+// shrinkPropertyFailure replaces the shortest failing random case, and its
+// result, with the smallest variant the submission still fails, so the
+// report shows a minimal counterexample. The score is unchanged: one failing
+// case is swapped for another.
+func (j *RunJob) shrinkPropertyFailure(executor Executor, results map[int]CaseResult) {
+    i := shortestFailure(j.Cases, func(i int) bool {
+        res, ok := results[i]
+        return !ok || !CasePassed(j.Cases[i], res)
+    })
+    if i < 0 {
+        return
+    }
+
+    if tc, res, ok := ShrinkFailure(executor, j.Challenge, j.UserCode, j.Cases[i]); ok {
+        j.Cases[i] = tc
+        results[i] = res
+    }
+}
+
+func (j *RunJob) fail(output string) {
+    j.publish(JobEvent{Type: "done", Status: JobFailed, Result: map[string]interface{}{
+        "passed": false,
+        "output": output,
+    }})
+}
+
+// --- Worker Pool ---
+
+// JobQueue runs submissions on a fixed number of workers. At most maxInFlight
+// jobs may be queued or running at once; beyond that Submit refuses new work.
+type JobQueue struct {
+    executor    Executor
+    pending     chan *RunJob
+    maxInFlight int
+
+    mu       sync.Mutex
+    jobs     map[string]*RunJob
+    inFlight int
+}
+
+func NewJobQueue(executor Executor, workers, maxInFlight int) *JobQueue {
+    q := &JobQueue{
+        executor:    executor,
+        pending:     make(chan *RunJob, maxInFlight),
+        maxInFlight: maxInFlight,
+        jobs:        make(map[string]*RunJob),
+    }
+    for i := 0; i < workers; i++ {
+        go q.worker()
+    }
+    return q
+}
+
+func (q *JobQueue) Submit(job *RunJob) error {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+
+    for id, old := range q.jobs {
+        old.mu.Lock()
+        expired := !old.finished.IsZero() && time.Since(old.finished) > jobRetention
+        old.mu.Unlock()
+        if expired {
+            delete(q.jobs, id)
+        }
+    }
+
+    if q.inFlight >= q.maxInFlight {
+        return ErrQueueFull
+    }
+
+    q.inFlight++
+    q.jobs[job.ID] = job
+    job.publish(JobEvent{Type: "status", Status: JobQueued})
+    q.pending <- job
+    return nil
+}
+
+func (q *JobQueue) Get(id string) (*RunJob, bool) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    job, ok := q.jobs[id]
+    return job, ok
+}
+
+func (q *JobQueue) worker() {
+    for job := range q.pending {
+        q.process(job)
+
+        q.mu.Lock()
+        q.inFlight--
+        q.mu.Unlock()
+    }
+}
+
+func (q *JobQueue) process(job *RunJob) {
+    defer func() {
+        if r := recover(); r != nil {
+            job.fail(fmt.Sprintf("System Error: %v", r))
+        }
+    }()
+
+    job.publish(JobEvent{Type: "status", Status: JobRunning})
+
+    if err := job.addPropertyCases(q.executor); err != nil {
+        job.fail("System Error: could not generate random tests: " + err.Error())
+        return
+    }
+
+    // Grade each case as soon as its line arrives when the backend streams;
+    // otherwise progress is published in one burst once the run completes.
+    reported := make(map[int]bool)
+    onLine := func(line string) {
+        res, ok, err := job.Run.ParseLine(line)
+        if err != nil || !ok || res.Index < 0 || res.Index >= len(job.Cases) || reported[res.Index] {
+            return
+        }
+        reported[res.Index] = true
+
+        tc := job.Cases[res.Index]
+        progress := CaseProgress{Index: res.Index, Passed: CasePassed(tc, res), Hidden: tc.Hidden, Property: tc.Property, Millis: res.Millis}
+        if tc.Class == ClassPerformance {
+            progress.BudgetMs = tc.Budget()
+        } else if !tc.Hidden {
+            progress.Input = tc.InputArgs
+        }
+        job.publish(JobEvent{Type: "case", Case: &progress})
+    }
+
+    var resp PistonResponse
+    var err error
+    if streamer, ok := q.executor.(StreamingExecutor); ok {
+        resp, err = streamer.ExecuteStream(job.Run.Request, onLine)
+    } else {
+        resp, err = q.executor.Execute(job.Run.Request)
+        if err == nil {
+            for _, line := range strings.Split(resp.Run.Stdout, "\n") {
+                onLine(line)
+            }
+        }
+    }
+
+    if errors.Is(err, ErrExecutorOffline) {
+        job.fail("Execution Engine Offline")
+        return
+    }
+    if err != nil {
+        job.fail(err.Error())
+        return
+    }
+
+    results, err := job.Run.Results(resp.Run.Stdout)
+    if err != nil {
+        job.fail("System Error: " + err.Error())
+        return
+    }
+
+    job.shrinkPropertyFailure(q.executor, results)
+
+    report := GradeCases(job.Cases, results, resp.Run)
+    report.ApplyHints(job.HintsUsed, job.HintPenalty)
+
+    submission := NewSubmission(&job.Challenge, job.UserCode, report, job.IpHash, job.UserAgent, job.Created)
+    submission.Handle = job.Handle
+
+    result := map[string]interface{}{
+        "passed":  report.Passed,
+        "score":   report.Score,
+        "output":  report.Output,
+        "wall_ms": report.WallMs,
+        "cpu_ms":  report.CpuMs,
+    }
+
+    if err := AddSubmission(&submission); err != nil {
+        fmt.Println("Submission not saved:", err)
+    } else {
+        result["submission_id"] = submission.Id
+        result["permalink"] = fmt.Sprintf("/challenges/submissions/%d", submission.Id)
+    }
+
+    job.publish(JobEvent{Type: "done", Status: JobDone, Result: result})
+}
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// answeringExecutor replies to python harness runs with the given outputs,
+// one result line per case, using the marker the harness passes on stdin.
+func answeringExecutor(outputs ...string) *FakeExecutor {
+    return NewFakeExecutor(func(req PistonRequest) (PistonResponse, error) {
+        marker := strings.TrimSpace(req.Stdin)
+        var out strings.Builder
+        for i, o := range outputs {
+            fmt.Fprintf(&out, "%s{\"index\":%d,\"output\":%q,\"ms\":1}\n", marker, i, o)
+        }
+        return PistonResponse{Run: PistonRun{Stdout: out.String(), WallTime: 5}}, nil
+    })
+}
+
+// testJob saves an "add" challenge and returns a job for it with the given cases.
+func testJob(t *testing.T, cases []TestCase) *RunJob {
+    t.Helper()
+    c := Challenge{Slug: fmt.Sprintf("add-%d", time.Now().UnixNano()), Title: "Add", FunctionName: "add", Type: "CODE", Language: "python", Version: 1}
+    if _, err := orm.NewOrm().Insert(&c); err != nil {
+        t.Fatal(err)
+    }
+
+    lang, _ := LookupLanguage("python")
+    run, err := NewHarnessRun(lang, "def add(a, b):\n    return a + b\n", "add", cases)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return NewRunJob(c, cases, run, "def add(a, b):\n    return a + b\n", "203.0.113.7", "go-test")
+}
+
+// collect drains a subscription, failing if the job doesn't finish in time.
+func collect(t *testing.T, ch <-chan JobEvent) []JobEvent {
+    t.Helper()
+    var events []JobEvent
+    timeout := time.After(5 * time.Second)
+    for {
+        select {
+        case ev, ok := <-ch:
+            if !ok {
+                return events
+            }
+            events = append(events, ev)
+        case <-timeout:
+            t.Fatalf("job did not finish; events so far: %+v", events)
+        }
+    }
+}
+
+func TestJobQueue(t *testing.T) {
+    clearTables(t, "submission", "challenge")
+    addCases := []TestCase{{InputArgs: "1, 2", ExpectedOutput: "3"}, {InputArgs: "2, 2", ExpectedOutput: "4", Hidden: true}}
+
+    tests := []struct {
+        name       string
+        executor   *FakeExecutor
+        wantStatus string
+        wantCases  int
+        wantPassed bool
+        wantOutput string
+    }{
+        {name: "all pass", executor: answeringExecutor("3", "4"), wantStatus: JobDone, wantCases: 2, wantPassed: true},
+        {name: "visible case fails", executor: answeringExecutor("4", "4"), wantStatus: JobDone, wantCases: 2, wantOutput: "✗ FAIL"},
+        {name: "hidden case fails", executor: answeringExecutor("3", "5"), wantStatus: JobDone, wantCases: 2, wantOutput: "✗ HIDDEN: 0/1 passed"},
+        {
+            name: "executor offline",
+            executor: NewFakeExecutor(func(PistonRequest) (PistonResponse, error) {
+                return PistonResponse{}, fmt.Errorf("dial: %w", ErrExecutorOffline)
+            }),
+            wantStatus: JobFailed,
+            wantOutput: "Execution Engine Offline",
+        },
+        {
+            name: "tampered harness output",
+            executor: NewFakeExecutor(func(req PistonRequest) (PistonResponse, error) {
+                line := strings.TrimSpace(req.Stdin) + `{"index":0,"output":"3"}` + "\n"
+                return PistonResponse{Run: PistonRun{Stdout: line + line}}, nil
+            }),
+            wantStatus: JobFailed,
+            wantCases:  1, // Streamed before the duplicate was seen
+            wantOutput: "System Error: duplicate result",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            queue := NewJobQueue(tt.executor, 1, 4)
+            job := testJob(t, addCases)
+            events, cancel := job.Subscribe()
+            defer cancel()
+
+            if err := queue.Submit(job); err != nil {
+                t.Fatal(err)
+            }
+            if got, ok := queue.Get(job.ID); !ok || got != job {
+                t.Fatalf("Get(%q) = %v, %v", job.ID, got, ok)
+            }
+
+            got := collect(t, events)
+            if len(got) < 3 || got[0].Status != JobQueued || got[1].Status != JobRunning {
+                t.Fatalf("events = %+v, want queued, running, ..., done", got)
+            }
+
+            var cases int
+            for _, ev := range got[2 : len(got)-1] {
+                if ev.Type != "case" {
+                    t.Errorf("unexpected %q event mid-run", ev.Type)
+                    continue
+                }
+                cases++
+                if ev.Case.Hidden && ev.Case.Input != "" {
+                    t.Errorf("hidden case %d published its input", ev.Case.Index)
+                }
+            }
+            if cases != tt.wantCases {
+                t.Errorf("got %d case events, want %d", cases, tt.wantCases)
+            }
+
+            done := got[len(got)-1]
+            if done.Type != "done" || done.Status != tt.wantStatus {
+                t.Fatalf("final event = %+v, want done/%s", done, tt.wantStatus)
+            }
+            if done.Result["passed"] != tt.wantPassed {
+                t.Errorf("passed = %v, want %v", done.Result["passed"], tt.wantPassed)
+            }
+            if out, _ := done.Result["output"].(string); !strings.Contains(out, tt.wantOutput) {
+                t.Errorf("output = %q, want it to contain %q", out, tt.wantOutput)
+            }
+            if tt.wantStatus == JobDone && done.Result["submission_id"] == nil {
+                t.Error("graded run was not saved as a submission")
+            }
+
+            snap := job.Snapshot()
+            if snap["status"] != tt.wantStatus || len(snap["cases"].([]CaseProgress)) != tt.wantCases {
+                t.Errorf("snapshot = %+v", snap)
+            }
+        })
+    }
+}
+
+func TestJobQueueSavesSubmission(t *testing.T) {
+    clearTables(t, "submission", "challenge")
+    queue := NewJobQueue(answeringExecutor("3"), 1, 4)
+    job := testJob(t, []TestCase{{InputArgs: "1, 2", ExpectedOutput: "3"}})
+    events, _ := job.Subscribe()
+    if err := queue.Submit(job); err != nil {
+        t.Fatal(err)
+    }
+    got := collect(t, events)
+
+    sub := Submission{Id: int(got[len(got)-1].Result["submission_id"].(int))}
+    if err := orm.NewOrm().Read(&sub); err != nil {
+        t.Fatal(err)
+    }
+    if !sub.Passed || sub.Score != 100 || sub.IpHash != HashIP("203.0.113.7") || sub.UserAgent != "go-test" {
+        t.Errorf("saved submission = %+v", sub)
+    }
+}
+
+func TestJobQueueFull(t *testing.T) {
+    clearTables(t, "submission", "challenge")
+    // No workers, so nothing leaves the queue
+    queue := NewJobQueue(answeringExecutor(), 0, 2)
+    cases := []TestCase{{InputArgs: "1, 2", ExpectedOutput: "3"}}
+
+    for i := 0; i < 2; i++ {
+        if err := queue.Submit(testJob(t, cases)); err != nil {
+            t.Fatalf("submit %d: %v", i, err)
+        }
+    }
+    if err := queue.Submit(testJob(t, cases)); !errors.Is(err, ErrQueueFull) {
+        t.Errorf("third submit: error = %v, want ErrQueueFull", err)
+    }
+}
+
+func TestRunJobSubscribe(t *testing.T) {
+    job := &RunJob{total: 1}
+    job.publish(JobEvent{Type: "status", Status: JobQueued})
+
+    early, _ := job.Subscribe()
+    left, cancel := job.Subscribe()
+    cancel()
+
+    job.publish(JobEvent{Type: "case", Case: &CaseProgress{Index: 0, Passed: true}})
+    job.publish(JobEvent{Type: "done", Status: JobDone, Result: map[string]interface{}{"passed": true}})
+
+    if got := collect(t, early); len(got) != 3 {
+        t.Errorf("subscriber saw %d events, want 3", len(got))
+    }
+    if got := len(left); got != 1 {
+        t.Errorf("cancelled subscriber received %d events, want only the replayed one", got)
+    }
+
+    // Subscribing after the end replays everything and closes at once
+    late, _ := job.Subscribe()
+    got := collect(t, late)
+    if len(got) != 3 || got[2].Type != "done" {
+        t.Errorf("late subscriber saw %+v", got)
+    }
+}
@@ -0,0 +1,343 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ===================================================================================
+// LANGUAGE REGISTRY
+// ===================================================================================
+
+// Language describes how to run challenge code written in one language: the
+// Piston runtime it maps to and how to wrap a submission in a test harness.
+type Language struct {
+    Name     string // Value stored in Challenge.Language
+    Runtime  string // Piston language identifier
+    Version  string // Piston runtime version
+    FileName string // Entry file handed to the runtime
+    Mode     string // CodeMirror mode for the editor
+
+    // harness returns the files to execute, entry file first
+    harness func(userCode, funcName, marker string, args []string) []PistonFile
+    // stdinMarker harnesses read the marker from stdin instead of embedding it,
+    // and run each case in a child process the submission can't tamper with
+    stdinMarker bool
+}
+
+var languages = map[string]Language{
+    "python": {
+        Name: "python", Runtime: "python", Version: "3.10.0",
+        FileName: "main.py", Mode: "python",
+        harness: pythonFiles, stdinMarker: true,
+    },
+    "javascript": {
+        Name: "javascript", Runtime: "javascript", Version: "18.15.0",
+        FileName: "main.js", Mode: "javascript",
+        harness: scriptFiles("main.js"),
+    },
+    "typescript": {
+        Name: "typescript", Runtime: "typescript", Version: "5.0.3",
+        FileName: "main.ts", Mode: "text/typescript",
+        harness: scriptFiles("main.ts"),
+    },
+    "go": {
+        Name: "go", Runtime: "go", Version: "1.16.2",
+        FileName: "main.go", Mode: "go",
+        harness: goFiles,
+    },
+}
+
+// Challenges authored before the registry existed have no language set.
+const defaultLanguage = "python"
+
+var identifierRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// LookupLanguage returns the registry entry for a Challenge.Language value.
+func LookupLanguage(name string) (Language, error) {
+    if name == "" {
+        name = defaultLanguage
+    }
+    lang, ok := languages[strings.ToLower(name)]
+    if !ok {
+        return Language{}, fmt.Errorf("unsupported language %q", name)
+    }
+    return lang, nil
+}
+
+// GetLanguages lists every supported language, for editors and admin forms.
+func GetLanguages() []Language {
+    return []Language{languages["python"], languages["javascript"], languages["typescript"], languages["go"]}
+}
+
+// --- Python ---
+
+// The Python harness never runs the submission itself: it reads the marker
+// from stdin, then feeds cases one at a time to a child interpreter. The
+// submission therefore has no way to learn the marker and forge result
+// lines, and each call is timed from the parent.
+func pythonFiles(userCode, funcName, marker string, args []string) []PistonFile {
+    code := strings.NewReplacer(
+        "{{FUNC}}", jsonLiteral(funcName),
+        "{{CODE}}", jsonLiteral(userCode),
+        "{{ARGS}}", jsonLiteral(args),
+        "{{RUNNER}}", jsonLiteral(pythonRunner),
+    ).Replace(pythonHarness)
+
+    return []PistonFile{{Name: "main.py", Content: code}}
+}
+
+const pythonHarness = `import json, secrets, subprocess, sys, time
+
+_MARKER = sys.stdin.readline().strip()
+_FUNC = {{FUNC}}
+_CODE = {{CODE}}
+_ARGS = {{ARGS}}
+_RUNNER = {{RUNNER}}
+
+class _Runner:
+    def __init__(self):
+        self.proc = subprocess.Popen([sys.executable, "-I", "-c", _RUNNER],
+            stdin=subprocess.PIPE, stdout=subprocess.PIPE, text=True, bufsize=1)
+        self.send({"code": _CODE, "func": _FUNC})
+
+    def send(self, msg):
+        self.proc.stdin.write(json.dumps(msg) + "\n")
+        self.proc.stdin.flush()
+
+    def receive(self, case_id):
+        line = self.proc.stdout.readline()
+        if not line:
+            raise RuntimeError("process exited while running the submission")
+        try:
+            msg = json.loads(line)
+        except ValueError:
+            msg = None
+        # Replies echo the case's id, so lines written by the submission
+        # (or left over from an earlier case) are never taken as results
+        if not isinstance(msg, dict) or msg.get("id") != case_id:
+            raise RuntimeError("the submission wrote to the harness's output channel")
+        return msg
+
+    def close(self):
+        self.proc.kill()
+        self.proc.wait()
+
+def _run_case(runner, index, args_src):
+    result = {"index": index, "output": "", "exception": "", "ms": 0.0}
+    # The child loads the submission and its arguments, then waits for "go"
+    # so only the call itself is timed.
+    case_id = secrets.token_hex(8)
+    runner.send({"id": case_id, "args": args_src})
+    msg = runner.receive(case_id)
+    if msg.get("ready"):
+        start = time.perf_counter()
+        runner.send({"go": True})
+        msg = runner.receive(case_id)
+        result["ms"] = (time.perf_counter() - start) * 1000
+    result["output"] = str(msg.get("output", ""))
+    result["exception"] = str(msg.get("exception", ""))
+    return result
+
+_runner = _Runner()
+for _i, _a in enumerate(_ARGS):
+    try:
+        _result = _run_case(_runner, _i, _a)
+    except (RuntimeError, OSError) as e:
+        _result = {"index": _i, "output": "", "exception": str(e), "ms": 0.0}
+        _runner.close()
+        _runner = _Runner()
+    sys.stdout.write(_MARKER + json.dumps(_result) + "\n")
+    sys.stdout.flush()
+_runner.close()
+`
+
+// pythonRunner runs in the child interpreter. The submission is executed
+// afresh for every case so state can't leak between cases.
+const pythonRunner = `import contextlib, io, json, linecache, sys, traceback
+
+_out, _in = sys.stdout, sys.stdin
+# stdin is the harness's channel; input() in the submission sees EOF
+sys.stdin = io.StringIO()
+_setup = json.loads(_in.readline())
+_CODE, _FUNC = _setup["code"], _setup["func"]
+del _setup
+
+linecache.cache["solution.py"] = (len(_CODE), None, _CODE.splitlines(True), "solution.py")
+_compiled = None
+
+def _send(msg):
+    _out.write(json.dumps(msg) + "\n")
+    _out.flush()
+
+def _format_exception(e):
+    frames = [f for f in traceback.extract_tb(e.__traceback__) if f.filename == "solution.py"]
+    return "".join(traceback.format_list(frames) + traceback.format_exception_only(type(e), e)).rstrip()
+
+while True:
+    _line = _in.readline()
+    if not _line:
+        break
+    _case = json.loads(_line)
+    _buf = io.StringIO()
+    _result = {"id": _case["id"], "output": "", "exception": ""}
+    try:
+        # Module-level prints are discarded; only the call itself is graded.
+        with contextlib.redirect_stdout(io.StringIO()):
+            if _compiled is None:
+                _compiled = compile(_CODE, "solution.py", "exec")
+            _ns = {"__name__": "__solution__"}
+            exec(_compiled, _ns)
+        _args = eval("(" + _case["args"] + ",)", {}) if _case["args"].strip() else ()
+        _fn = _ns.get(_FUNC)
+        if not callable(_fn):
+            raise NameError("function '%s' is not defined" % _FUNC)
+        _send({"id": _case["id"], "ready": True})
+        _in.readline()
+        with contextlib.redirect_stdout(_buf):
+            print(_fn(*_args))
+    except BaseException as e:
+        _result["exception"] = _format_exception(e)
+    _result["output"] = _buf.getvalue()
+    _send(_result)
+`
+
+// --- JavaScript / TypeScript ---
+
+// scriptFiles appends the harness to the submission in one file. The harness
+// is plain JavaScript that also type-checks as (non-strict) TypeScript, so
+// both languages share it. Arguments are spliced in as source literals.
+// Sharing a process, the submission can reach the marker and the clock, so
+// these languages get no performance cases (see CheckTimedCases).
+func scriptFiles(fileName string) func(string, string, string, []string) []PistonFile {
+    return func(userCode, funcName, marker string, args []string) []PistonFile {
+        var calls strings.Builder
+        for _, a := range args {
+            calls.WriteString(fmt.Sprintf("    function () { return %s(%s); },\n", funcName, a))
+        }
+
+        code := strings.NewReplacer(
+            "{{MARKER}}", jsonLiteral(marker),
+            "{{FUNC}}", funcName,
+            "{{CALLS}}", calls.String(),
+        ).Replace(scriptHarness)
+
+        return []PistonFile{{Name: fileName, Content: userCode + "\n" + code}}
+    }
+}
+
+const scriptHarness = `
+// ---- test harness ----
+const __proc = globalThis["process"];
+const __log = console.log;
+const __calls = [
+{{CALLS}}];
+
+function __format(v) {
+    if (typeof v === "string") return v;
+    if (v === undefined) return "undefined";
+    return JSON.stringify(v);
+}
+
+for (let __i = 0; __i < __calls.length; __i++) {
+    const result = { index: __i, output: "", exception: "", ms: 0 };
+    const lines = [];
+    console.log = function (...parts) { lines.push(parts.map(__format).join(" ")); };
+    const start = Date.now();
+    try {
+        if (typeof {{FUNC}} !== "function") throw new ReferenceError("function '{{FUNC}}' is not defined");
+        lines.push(__format(__calls[__i]()));
+    } catch (e) {
+        result.exception = e && e.stack ? String(e.stack).split("\n").slice(0, 4).join("\n") : String(e);
+    }
+    result.ms = Date.now() - start;
+    console.log = __log;
+    result.output = lines.length ? lines.join("\n") + "\n" : "";
+    __proc.stdout.write({{MARKER}} + JSON.stringify(result) + "\n");
+}
+`
+
+// --- Go ---
+
+// goFiles keeps the submission in its own file so its imports can't clash
+// with the harness's. Each case is a closure compiled into main.go. As with
+// scriptFiles, the submission shares the harness's process, so Go challenges
+// get no performance cases.
+func goFiles(userCode, funcName, marker string, args []string) []PistonFile {
+    if !strings.HasPrefix(strings.TrimSpace(userCode), "package ") {
+        userCode = "package main\n\n" + userCode
+    }
+
+    var calls strings.Builder
+    for _, a := range args {
+        calls.WriteString(fmt.Sprintf("\tfunc() interface{} { return %s(%s) },\n", funcName, a))
+    }
+
+    code := strings.NewReplacer(
+        "{{MARKER}}", jsonLiteral(marker),
+        "{{CALLS}}", calls.String(),
+    ).Replace(goHarness)
+
+    return []PistonFile{
+        {Name: "main.go", Content: code},
+        {Name: "solution.go", Content: userCode},
+    }
+}
+
+const goHarness = `package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+var harnessCalls = []func() interface{}{
+{{CALLS}}}
+
+func harnessRun(index int, call func() interface{}) {
+	result := map[string]interface{}{"index": index, "output": "", "exception": "", "ms": 0.0}
+
+	stdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	captured := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		captured <- string(b)
+	}()
+
+	start := time.Now()
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				trace := strings.Split(string(debug.Stack()), "\n")
+				if len(trace) > 12 {
+					trace = trace[:12]
+				}
+				result["exception"] = fmt.Sprintf("panic: %v\n%s", p, strings.Join(trace, "\n"))
+			}
+		}()
+		fmt.Fprintln(w, call())
+	}()
+	result["ms"] = float64(time.Since(start).Microseconds()) / 1000
+
+	w.Close()
+	os.Stdout = stdout
+	result["output"] = <-captured
+
+	line, _ := json.Marshal(result)
+	fmt.Fprintln(stdout, {{MARKER}}+string(line))
+}
+
+func main() {
+	for i, call := range harnessCalls {
+		harnessRun(i, call)
+	}
+}
+`
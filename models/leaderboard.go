@@ -0,0 +1,264 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// ===================================================================================
+// LEADERBOARD & CERTIFICATES
+// ===================================================================================
+
+// Handles appear in certificate URLs, so on top of ValidateName they are
+// limited to a URL-safe character set.
+var handleRe = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,30}$`)
+
+// ClaimHandle validates name and creates the handle. Names are unique
+// regardless of case. The returned token proves ownership from then on;
+// only its hash is stored, so it can't be recovered if lost.
+func ClaimHandle(name string) (*Handle, string, error) {
+    name = strings.TrimSpace(name)
+    if err := ValidateName(name); err != nil {
+        return nil, "", err
+    }
+    if !handleRe.MatchString(name) {
+        return nil, "", errors.New("handle must be 3-30 letters, digits, '.', '_' or '-'")
+    }
+
+    o := orm.NewOrm()
+    if o.QueryTable("handle").Filter("name__iexact", name).Exist() {
+        return nil, "", errors.New("handle is already taken")
+    }
+
+    secret := make([]byte, 16)
+    if _, err := rand.Read(secret); err != nil {
+        return nil, "", err
+    }
+
+    h := &Handle{Name: name, ClaimHash: claimHash(hex.EncodeToString(secret))}
+    if _, err := o.Insert(h); err != nil {
+        return nil, "", errors.New("handle is already taken")
+    }
+    return h, fmt.Sprintf("%d.%x", h.Id, secret), nil
+}
+
+// HandleFromToken returns the handle a ClaimHandle token was issued for.
+func HandleFromToken(token string) (*Handle, error) {
+    id, secret, ok := strings.Cut(token, ".")
+    n, err := strconv.Atoi(id)
+    if !ok || err != nil || secret == "" {
+        return nil, errors.New("malformed handle token")
+    }
+
+    h, err := GetHandleById(n)
+    if err != nil {
+        return nil, err
+    }
+    if h.ClaimHash == "" || subtle.ConstantTimeCompare([]byte(claimHash(secret)), []byte(h.ClaimHash)) != 1 {
+        return nil, errors.New("handle token does not match")
+    }
+    return h, nil
+}
+
+func claimHash(secret string) string {
+    sum := sha256.Sum256([]byte(secret))
+    return hex.EncodeToString(sum[:])
+}
+
+func GetHandleById(id int) (*Handle, error) {
+    o := orm.NewOrm()
+    h := &Handle{Id: id}
+    err := o.Read(h)
+    return h, err
+}
+
+func GetHandleByName(name string) (*Handle, error) {
+    o := orm.NewOrm()
+    h := &Handle{}
+    err := o.QueryTable("handle").Filter("name__iexact", name).One(h)
+    return h, err
+}
+
+// recordSolve is called for a passing submission by a handle. Later passes
+// of the same challenge are ignored.
+func recordSolve(o orm.Ormer, sub *Submission) error {
+    qs := o.QueryTable("solve").Filter("Handle__Id", sub.Handle.Id).Filter("Challenge__Id", sub.Challenge.Id)
+    if qs.Exist() {
+        return nil
+    }
+
+    var attempts []Submission
+    o.QueryTable("submission").
+        Filter("Handle__Id", sub.Handle.Id).
+        Filter("Challenge__Id", sub.Challenge.Id).
+        OrderBy("id").
+        Limit(-1).
+        All(&attempts, "Id", "Created")
+
+    solve := &Solve{
+        Handle:    sub.Handle,
+        Challenge: sub.Challenge,
+        Attempts:  len(attempts),
+        Score:     sub.Score,
+    }
+    if len(attempts) > 0 {
+        solve.Seconds = int(sub.Created.Sub(attempts[0].Created).Seconds())
+    }
+
+    _, err := o.Insert(solve)
+    return err
+}
+
+// GetSolves returns a handle's solves in the order they happened, with
+// challenges loaded.
+func GetSolves(handleId int) []Solve {
+    o := orm.NewOrm()
+    var solves []Solve
+    o.QueryTable("solve").Filter("Handle__Id", handleId).RelatedSel("Challenge").OrderBy("id").Limit(-1).All(&solves)
+    return solves
+}
+
+// SolveTime formats Seconds for display.
+func (s Solve) SolveTime() string {
+    return FormatSeconds(s.Seconds)
+}
+
+// --- Leaderboard ---
+
+type LeaderboardEntry struct {
+    Rank    int
+    Handle  string
+    Solved  int
+    Score   float64 // Sum of the passing scores
+    Seconds int     // Sum of the solve times
+    Last    time.Time
+}
+
+// SolveTime formats Seconds for display.
+func (e LeaderboardEntry) SolveTime() string {
+    return FormatSeconds(e.Seconds)
+}
+
+// GetLeaderboard ranks handles by challenges solved, then total score, then
+// total solve time.
+func GetLeaderboard(limit int) []LeaderboardEntry {
+    o := orm.NewOrm()
+    var solves []Solve
+    o.QueryTable("solve").RelatedSel("Handle").OrderBy("id").Limit(-1).All(&solves)
+    return RankSolves(solves, limit)
+}
+
+// RankSolves aggregates solves (with Handle loaded) into leaderboard rows.
+func RankSolves(solves []Solve, limit int) []LeaderboardEntry {
+    byHandle := make(map[int]*LeaderboardEntry)
+    var entries []*LeaderboardEntry
+    for _, s := range solves {
+        if s.Handle == nil {
+            continue
+        }
+        e, ok := byHandle[s.Handle.Id]
+        if !ok {
+            e = &LeaderboardEntry{Handle: s.Handle.Name}
+            byHandle[s.Handle.Id] = e
+            entries = append(entries, e)
+        }
+        e.Solved++
+        e.Score += s.Score
+        e.Seconds += s.Seconds
+        if s.Created.After(e.Last) {
+            e.Last = s.Created
+        }
+    }
+
+    // Stable over insertion order, so on a full tie whoever got there first
+    // stays ahead.
+    sort.SliceStable(entries, func(i, j int) bool {
+        a, b := entries[i], entries[j]
+        if a.Solved != b.Solved {
+            return a.Solved > b.Solved
+        }
+        if a.Score != b.Score {
+            return a.Score > b.Score
+        }
+        return a.Seconds < b.Seconds
+    })
+
+    if limit > 0 && len(entries) > limit {
+        entries = entries[:limit]
+    }
+    out := make([]LeaderboardEntry, len(entries))
+    for i, e := range entries {
+        e.Rank = i + 1
+        out[i] = *e
+    }
+    return out
+}
+
+func FormatSeconds(seconds int) string {
+    d := time.Duration(seconds) * time.Second
+    if d < time.Hour {
+        return fmt.Sprintf("%dm%02ds", int(d.Minutes()), seconds%60)
+    }
+    return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// --- Certificates ---
+
+// A certificate covers a handle's first N solves. Solves are only ever
+// appended, so a signature issued earlier still verifies against the same
+// prefix after the handle solves more.
+
+const certificateSigLength = 12
+
+// CertificateSecret is the server-side HMAC key; certificates are unsigned
+// without it.
+func CertificateSecret() string {
+    return os.Getenv("CERTIFICATE_SECRET")
+}
+
+// SignCertificate signs the handle and its solves, in order, in the same
+// short hex form as AccessLog.Signature but keyed with the server secret.
+func SignCertificate(secret, handle string, solves []Solve) string {
+    if secret == "" {
+        return ""
+    }
+    mac := hmac.New(sha1.New, []byte(secret))
+    mac.Write([]byte(strings.ToLower(handle)))
+    for _, s := range solves {
+        cid := 0
+        if s.Challenge != nil {
+            cid = s.Challenge.Id
+        }
+        fmt.Fprintf(mac, "|%d:%d", cid, s.Created.Unix())
+    }
+    return hex.EncodeToString(mac.Sum(nil))[:certificateSigLength]
+}
+
+// VerifyCertificate finds how many of the handle's solves sig covers.
+// It returns 0 when sig matches no prefix.
+func VerifyCertificate(secret, handle string, solves []Solve, sig string) int {
+    sig = strings.ToLower(strings.TrimSpace(sig))
+    if secret == "" || len(sig) != certificateSigLength {
+        return 0
+    }
+    for n := len(solves); n > 0; n-- {
+        if hmac.Equal([]byte(sig), []byte(SignCertificate(secret, handle, solves[:n]))) {
+            return n
+        }
+    }
+    return 0
+}
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+func TestRankSolves(t *testing.T) {
+    ada := &Handle{Id: 1, Name: "ada"}
+    bob := &Handle{Id: 2, Name: "bob"}
+    cy := &Handle{Id: 3, Name: "cy"}
+    day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+    type row struct {
+        Handle  string
+        Solved  int
+        Score   float64
+        Seconds int
+    }
+    tests := []struct {
+        name   string
+        solves []Solve
+        limit  int
+        want   []row
+    }{
+        {
+            name: "more solves first",
+            solves: []Solve{
+                {Handle: ada, Score: 100, Seconds: 10},
+                {Handle: bob, Score: 100, Seconds: 10},
+                {Handle: bob, Score: 100, Seconds: 10},
+            },
+            want: []row{{"bob", 2, 200, 20}, {"ada", 1, 100, 10}},
+        },
+        {
+            name: "then higher score",
+            solves: []Solve{
+                {Handle: ada, Score: 80, Seconds: 5},
+                {Handle: bob, Score: 90, Seconds: 50},
+            },
+            want: []row{{"bob", 1, 90, 50}, {"ada", 1, 80, 5}},
+        },
+        {
+            name: "then faster",
+            solves: []Solve{
+                {Handle: ada, Score: 100, Seconds: 60},
+                {Handle: bob, Score: 100, Seconds: 30},
+            },
+            want: []row{{"bob", 1, 100, 30}, {"ada", 1, 100, 60}},
+        },
+        {
+            name: "full tie keeps first solver ahead",
+            solves: []Solve{
+                {Handle: cy, Score: 100, Seconds: 30},
+                {Handle: ada, Score: 100, Seconds: 30},
+            },
+            want: []row{{"cy", 1, 100, 30}, {"ada", 1, 100, 30}},
+        },
+        {
+            name: "limit and missing handles",
+            solves: []Solve{
+                {Handle: nil, Score: 100},
+                {Handle: ada, Score: 100},
+                {Handle: bob, Score: 90},
+                {Handle: cy, Score: 80},
+            },
+            limit: 2,
+            want:  []row{{"ada", 1, 100, 0}, {"bob", 1, 90, 0}},
+        },
+        {
+            name: "empty",
+            want: []row{},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            for i := range tt.solves {
+                tt.solves[i].Created = day.Add(time.Duration(i) * time.Hour)
+            }
+
+            entries := RankSolves(tt.solves, tt.limit)
+            got := make([]row, len(entries))
+            for i, e := range entries {
+                if e.Rank != i+1 {
+                    t.Errorf("entry %d has rank %d", i, e.Rank)
+                }
+                got[i] = row{e.Handle, e.Solved, e.Score, e.Seconds}
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("RankSolves = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestFormatSeconds(t *testing.T) {
+    tests := []struct {
+        seconds int
+        want    string
+    }{
+        {0, "0m00s"},
+        {59, "0m59s"},
+        {61, "1m01s"},
+        {3599, "59m59s"},
+        {3600, "1h00m"},
+        {3*3600 + 25*60 + 59, "3h25m"},
+        {30 * 3600, "30h00m"},
+    }
+
+    for _, tt := range tests {
+        if got := FormatSeconds(tt.seconds); got != tt.want {
+            t.Errorf("FormatSeconds(%d) = %q, want %q", tt.seconds, got, tt.want)
+        }
+    }
+}
+
+func TestCertificates(t *testing.T) {
+    const secret = "test-secret"
+    day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+    solves := []Solve{
+        {Challenge: &Challenge{Id: 3}, Created: day},
+        {Challenge: &Challenge{Id: 1}, Created: day.Add(time.Hour)},
+        {Challenge: &Challenge{Id: 7}, Created: day.Add(2 * time.Hour)},
+    }
+    twoSolves := SignCertificate(secret, "Ada", solves[:2])
+
+    if len(twoSolves) != certificateSigLength {
+        t.Fatalf("signature %q has length %d, want %d", twoSolves, len(twoSolves), certificateSigLength)
+    }
+    if SignCertificate("", "Ada", solves) != "" {
+        t.Error("signed without a secret")
+    }
+
+    tests := []struct {
+        name   string
+        secret string
+        handle string
+        solves []Solve
+        sig    string
+        want   int
+    }{
+        {"still verifies after more solves", secret, "Ada", solves, twoSolves, 2},
+        {"exact signed prefix", secret, "Ada", solves[:2], twoSolves, 2},
+        {"handle is case-insensitive", secret, "ADA", solves, twoSolves, 2},
+        {"upper-case signature", secret, "Ada", solves, "  " + strings.ToUpper(twoSolves) + " ", 2},
+        {"full history", secret, "Ada", solves, SignCertificate(secret, "Ada", solves), 3},
+        {"other handle", secret, "bob", solves, twoSolves, 0},
+        {"other secret", "different", "Ada", solves, twoSolves, 0},
+        {"no secret", "", "Ada", solves, twoSolves, 0},
+        {"truncated signature", secret, "Ada", solves, twoSolves[:8], 0},
+        {"reordered solves", secret, "Ada", []Solve{solves[1], solves[0]}, twoSolves, 0},
+        {"no solves", secret, "Ada", nil, twoSolves, 0},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := VerifyCertificate(tt.secret, tt.handle, tt.solves, tt.sig); got != tt.want {
+                t.Errorf("VerifyCertificate = %d, want %d", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestClaimHandle(t *testing.T) {
+    clearTables(t, "solve", "submission", "handle")
+
+    h, token, err := ClaimHandle("  grace_h ")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if h.Name != "grace_h" || strings.Contains(h.ClaimHash, strings.SplitN(token, ".", 2)[1]) {
+        t.Errorf("handle = %+v, want the trimmed name and only a hash of the token", h)
+    }
+    if got, err := HandleFromToken(token); err != nil || got.Id != h.Id {
+        t.Errorf("HandleFromToken = %+v, %v", got, err)
+    }
+
+    for _, name := range []string{"GRACE_H", "x", "has space"} {
+        if _, _, err := ClaimHandle(name); err == nil {
+            t.Errorf("ClaimHandle(%q) succeeded", name)
+        }
+    }
+    for _, bad := range []string{"", "nodot", fmt.Sprintf("%d.", h.Id), fmt.Sprintf("%d.%s", h.Id, strings.Repeat("0", 32)), "999.abc"} {
+        if _, err := HandleFromToken(bad); err == nil {
+            t.Errorf("HandleFromToken(%q) succeeded", bad)
+        }
+    }
+}
+
+func TestAddSubmissionRecordsFirstSolve(t *testing.T) {
+    clearTables(t, "solve", "submission", "handle", "challenge")
+    o := orm.NewOrm()
+    c := &Challenge{Slug: "solvable", Title: "Solvable"}
+    if _, err := o.Insert(c); err != nil {
+        t.Fatal(err)
+    }
+    h, _, err := ClaimHandle("solver")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    for _, passed := range []bool{false, false, true, true} {
+        sub := &Submission{Challenge: c, Handle: h, Passed: passed, Score: map[bool]float64{true: 90}[passed]}
+        if err := AddSubmission(sub); err != nil {
+            t.Fatal(err)
+        }
+    }
+    // Anonymous passes never count
+    if err := AddSubmission(&Submission{Challenge: c, Passed: true, Score: 100}); err != nil {
+        t.Fatal(err)
+    }
+
+    solves := GetSolves(h.Id)
+    if len(solves) != 1 {
+        t.Fatalf("got %d solves, want 1", len(solves))
+    }
+    if s := solves[0]; s.Attempts != 3 || s.Score != 90 || s.Challenge.Title != "Solvable" {
+        t.Errorf("solve = %+v, want the third attempt's pass", s)
+    }
+}
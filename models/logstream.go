@@ -0,0 +1,75 @@
+package models
+
+import (
+	"sync"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// ===================================================================================
+// GUESTBOOK STREAM
+// ===================================================================================
+
+// LogBroker fans newly visible guestbook entries out to subscribers, such as
+// the /logs/stream SSE handler. It is in-process only: entries approved on
+// another instance reach its own subscribers, not these.
+type LogBroker struct {
+    Buffer int // Per-subscriber channel size
+
+    mu   sync.Mutex
+    subs map[chan AccessLog]struct{}
+}
+
+func NewLogBroker(buffer int) *LogBroker {
+    return &LogBroker{Buffer: buffer, subs: make(map[chan AccessLog]struct{})}
+}
+
+// LogEvents receives every entry as it becomes approved, whether on submit
+// or from the moderation queue.
+var LogEvents = NewLogBroker(16)
+
+// Subscribe returns a channel of new entries and a func that must be called
+// to stop receiving them.
+func (b *LogBroker) Subscribe() (<-chan AccessLog, func()) {
+    ch := make(chan AccessLog, b.Buffer)
+
+    b.mu.Lock()
+    b.subs[ch] = struct{}{}
+    b.mu.Unlock()
+
+    return ch, func() {
+        b.mu.Lock()
+        delete(b.subs, ch)
+        b.mu.Unlock()
+    }
+}
+
+// Publish sends log to every subscriber without blocking. A subscriber whose
+// buffer is full misses the entry; it will see it on its next page load.
+func (b *LogBroker) Publish(log AccessLog) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    for sub := range b.subs {
+        select {
+        case sub <- log:
+        default:
+        }
+    }
+}
+
+// Subscribers reports how many channels are listening.
+func (b *LogBroker) Subscribers() int {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return len(b.subs)
+}
+
+// GetAccessLogsAfter returns up to limit approved entries with an id above
+// afterId, oldest first, so a reconnecting stream can catch up.
+func GetAccessLogsAfter(afterId, limit int) []AccessLog {
+    o := orm.NewOrm()
+    var logs []AccessLog
+    o.QueryTable("access_log").Filter("Status", LogApproved).Filter("Id__gt", afterId).OrderBy("id").Limit(limit).All(&logs)
+    return logs
+}
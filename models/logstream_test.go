@@ -0,0 +1,68 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+func TestLogBroker(t *testing.T) {
+    b := NewLogBroker(1)
+    first, cancelFirst := b.Subscribe()
+    second, cancelSecond := b.Subscribe()
+    if b.Subscribers() != 2 {
+        t.Fatalf("Subscribers() = %d, want 2", b.Subscribers())
+    }
+
+    b.Publish(AccessLog{Id: 1})
+    // Buffers are full now; publishing must drop rather than block
+    b.Publish(AccessLog{Id: 2})
+
+    for name, ch := range map[string]<-chan AccessLog{"first": first, "second": second} {
+        if got := <-ch; got.Id != 1 {
+            t.Errorf("%s subscriber got entry %d, want 1", name, got.Id)
+        }
+        if len(ch) != 0 {
+            t.Errorf("%s subscriber got the entry published while it was full", name)
+        }
+    }
+
+    cancelFirst()
+    b.Publish(AccessLog{Id: 3})
+    if len(first) != 0 {
+        t.Error("unsubscribed channel still received entries")
+    }
+    if got := <-second; got.Id != 3 {
+        t.Errorf("second subscriber got entry %d, want 3", got.Id)
+    }
+
+    cancelSecond()
+    if b.Subscribers() != 0 {
+        t.Errorf("Subscribers() = %d after unsubscribing", b.Subscribers())
+    }
+}
+
+func TestGetAccessLogsAfter(t *testing.T) {
+    clearTables(t, "access_log")
+    o := orm.NewOrm()
+    var ids []int
+    for _, status := range []string{LogApproved, LogPending, LogApproved, LogApproved} {
+        log := AccessLog{Name: "ada", Message: "hi", Status: status}
+        if _, err := o.Insert(&log); err != nil {
+            t.Fatal(err)
+        }
+        ids = append(ids, log.Id)
+    }
+
+    // Oldest first, approved only, limited
+    got := GetAccessLogsAfter(ids[0], 1)
+    if len(got) != 1 || got[0].Id != ids[2] {
+        t.Errorf("GetAccessLogsAfter(%d, 1) = %+v, want entry %d", ids[0], got, ids[2])
+    }
+    if got := GetAccessLogsAfter(ids[0], 10); len(got) != 2 || got[1].Id != ids[3] {
+        t.Errorf("GetAccessLogsAfter(%d, 10) = %+v", ids[0], got)
+    }
+    if got := GetAccessLogsAfter(ids[3], 10); len(got) != 0 {
+        t.Errorf("nothing after the newest, got %+v", got)
+    }
+}
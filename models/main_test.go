@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// TestMain points the ORM at a throwaway sqlite database so tests that save
+// rows run without Postgres. Queries written in Postgres-only SQL are left to
+// the integration tests in tests/.
+func TestMain(m *testing.M) {
+    dir, err := os.MkdirTemp("", "models-test")
+    if err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+
+    os.Setenv("IP_HASH_SALT", "test-salt")
+    if err := orm.RegisterDataBase("default", "sqlite3", filepath.Join(dir, "test.db")+"?_fk=1"); err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+    if err := orm.RunSyncdb("default", false, false); err != nil {
+        fmt.Println(err)
+        os.Exit(1)
+    }
+
+    code := m.Run()
+    os.RemoveAll(dir)
+    os.Exit(code)
+}
+
+// clearTables empties the named tables so each test starts from a known state.
+func clearTables(t *testing.T, tables ...string) {
+    t.Helper()
+    o := orm.NewOrm()
+    for _, table := range tables {
+        if _, err := o.Raw("DELETE FROM " + table).Exec(); err != nil {
+            t.Fatal(err)
+        }
+    }
+}
@@ -1,17 +1,21 @@
 package models
 
 import (
-	"bytes"
+	"context"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
-	"net/http"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/server/web"
 	_ "github.com/lib/pq"
 )
 
@@ -21,33 +25,92 @@ import (
 
 // --- Access Log Model ---
 type AccessLog struct {
-    Id        int       `orm:"auto"`
-    Name      string    `orm:"size(50)"`
-    Message   string    `orm:"type(text)"`
-    Signature string    `orm:"size(10)"`
-    Terminal  string    `orm:"size(30)"`
-    ProcessID int
-    Created   time.Time `orm:"auto_now_add;type(datetime)"`
+    Id         int       `orm:"auto"`
+    Name       string    `orm:"size(50)"`
+    Message    string    `orm:"type(text)"`
+    Signature  string    `orm:"size(10)"`
+    Terminal   string    `orm:"size(30)"`
+    ProcessID  int
+    Status     string    `orm:"size(20);default(approved);index"` // See LogPending etc.
+    Moderation string    `orm:"type(text);null"`                  // JSON verdicts for name and message
+    Created    time.Time `orm:"auto_now_add;type(datetime)"`
 }
 
 func (u *AccessLog) TableName() string {
     return "access_log"
 }
 
+// --- Rate Limit Model ---
+// Fixed-window hit counter, used by DBRateLimiter.
+type RateLimitEntry struct {
+    Id        int       `orm:"auto"`
+    Bucket    string    `orm:"size(191);unique"` // route:hashed-ip
+    Count     int
+    ExpiresAt time.Time `orm:"type(datetime);index"`
+}
+
+func (u *RateLimitEntry) TableName() string {
+    return "rate_limit"
+}
+
+// --- App Secret Model ---
+// Server-generated secrets that must survive restarts and be shared by every
+// instance, such as the IP hash salt when IP_HASH_SALT is unset.
+type AppSecret struct {
+    Id      int       `orm:"auto"`
+    Name    string    `orm:"size(50);unique"`
+    Value   string    `orm:"size(128)"`
+    Created time.Time `orm:"auto_now_add;type(datetime)"`
+}
+
+func (u *AppSecret) TableName() string {
+    return "app_secret"
+}
+
+// --- Moderation Action Model ---
+// Audit record of an admin decision on an access log entry. LogId is kept
+// as a plain column so the record survives deleting the entry.
+type ModerationAction struct {
+    Id         int       `orm:"auto"`
+    LogId      int       `orm:"index"`
+    Action     string    `orm:"size(20)"` // approve | reject | delete
+    FromStatus string    `orm:"size(20)"`
+    ToStatus   string    `orm:"size(20)"`
+    Admin      string    `orm:"size(50)"`
+    Summary    string    `orm:"size(120)"` // "name: message", truncated
+    Created    time.Time `orm:"auto_now_add;type(datetime)"`
+}
+
+func (u *ModerationAction) TableName() string {
+    return "moderation_action"
+}
+
 // --- Challenge Model ---
 // --- Challenge Model ---
 type Challenge struct {
-    Id           int         `orm:"auto"`
-    Title        string      `orm:"size(255)"`
-    Description  string      `orm:"type(text)"`
-    InputHint    string      `orm:"size(255)"`
-    FunctionName string      `orm:"size(100)"`
-    Difficulty   string      `orm:"size(50)"`
-    Category     string      `orm:"size(50)"`
-    Type         string      `orm:"size(50)"`
-    Language     string      `orm:"size(50);null"`
-    StarterCode  string      `orm:"type(text);null"`
-    TestCases    []*TestCase `orm:"reverse(many)"`
+    Id                int         `orm:"auto"`
+    Slug              string      `orm:"size(100);unique;null"` // Stable identity across renames
+    Title             string      `orm:"size(255)"`
+    Description       string      `orm:"type(text)"`
+    InputHint         string      `orm:"size(255)"`
+    FunctionName      string      `orm:"size(100)"`
+    Difficulty        string      `orm:"size(50)"`
+    Category          string      `orm:"size(50)"`
+    Type              string      `orm:"size(50)"`
+    Language          string      `orm:"size(50);null"`
+    StarterCode       string      `orm:"type(text);null"`
+    ReferenceSolution string      `orm:"type(text);null"` // Known-good answer, never sent to clients
+    Generator         string      `orm:"type(text);null"`  // Python input generator, see property.go
+    PropertyCases     int         `orm:"default(0)"`       // Random cases per run; 0 uses the config default
+    Choices           string      `orm:"type(text);null"`  // MCQ: JSON array of choice texts
+    Answer            string      `orm:"type(text);null"`  // MCQ/PREDICT answer, never sent to clients
+    Fixture           string      `orm:"type(text);null"`  // SQL: schema and rows loaded before each query
+    SeedFile          string      `orm:"size(255);null"`   // Source file under challenges/, if seeded
+    AdminOwned        bool        `orm:"default(false)"`   // Edited in /admin; the seeder leaves it alone
+    Version           int         `orm:"default(1)"`       // Bumped whenever ContentHash changes
+    ContentHash       string      `orm:"size(64);null"`    // See ContentHash in versions.go
+    Published         bool        `orm:"default(true)"`    // Hidden from visitors until set
+    TestCases         []*TestCase `orm:"reverse(many)"`
 }
 
 // Force table name to singular 'challenge' to match DB creation default
@@ -61,6 +124,14 @@ type TestCase struct {
     Challenge      *Challenge `orm:"rel(fk);on_delete(cascade)"`
     InputArgs      string     `orm:"type(text)"`
     ExpectedOutput string     `orm:"type(text)"`
+    Comparator     string     `orm:"size(20);default(exact)"`      // See compare.go
+    CompareArg     string     `orm:"size(255);null"`               // e.g. float tolerance
+    Hidden         bool       `orm:"default(false)"`               // Only counted, never echoed back
+    Weight         float64    `orm:"default(1)"`                   // Share of the challenge score
+    Class          string     `orm:"size(20);default(correctness)"` // correctness | performance
+    BudgetMs       float64    `orm:"default(0)"`                   // Time limit for the call itself; 0 = class default
+    Version        int        `orm:"default(1)"`                   // Challenge version this case belongs to
+    Property       bool       `orm:"-"`                            // Generated for this run, never stored
 }
 
 // Force table name to singular 'test_case' to match DB creation default
@@ -68,14 +139,131 @@ func (u *TestCase) TableName() string {
     return "test_case"
 }
 
+// --- Challenge Revision Model ---
+// Snapshot of a challenge's graded content, one per version. Test cases of
+// old versions are kept alongside, so past submissions still resolve.
+type ChallengeRevision struct {
+    Id          int        `orm:"auto"`
+    Challenge   *Challenge `orm:"rel(fk);on_delete(cascade)"`
+    Version     int
+    ContentHash string     `orm:"size(64)"`
+    Snapshot    string     `orm:"type(text)"` // JSON, see RevisionSnapshot
+    Created     time.Time  `orm:"auto_now_add;type(datetime)"`
+}
+
+func (u *ChallengeRevision) TableName() string {
+    return "challenge_revision"
+}
+
+func (u *ChallengeRevision) TableUnique() [][]string {
+    return [][]string{{"Challenge", "Version"}}
+}
+
+// --- Hint Model ---
+// Hints are revealed in Position order; each one taken costs Penalty
+// percentage points off the score.
+type Hint struct {
+    Id        int        `orm:"auto"`
+    Challenge *Challenge `orm:"rel(fk);on_delete(cascade)"`
+    Position  int
+    Text      string     `orm:"type(text)"`
+    Penalty   float64    `orm:"default(10)"`
+}
+
+func (u *Hint) TableName() string {
+    return "hint"
+}
+
+// --- Handle Model ---
+// A public leaderboard name. The claiming browser keeps a token whose hash
+// is ClaimHash, so the claim outlives its session.
+type Handle struct {
+    Id        int       `orm:"auto"`
+    Name      string    `orm:"size(50);unique"`
+    ClaimHash string    `orm:"size(64);null"` // sha256 of the secret in the claimer's token
+    Created   time.Time `orm:"auto_now_add;type(datetime)"`
+}
+
+func (u *Handle) TableName() string {
+    return "handle"
+}
+
+// --- Solve Model ---
+// First passing submission of a challenge by a handle.
+type Solve struct {
+    Id        int        `orm:"auto"`
+    Handle    *Handle    `orm:"rel(fk);on_delete(cascade)"`
+    Challenge *Challenge `orm:"rel(fk);on_delete(cascade)"`
+    Attempts  int        // Submissions up to and including the pass
+    Seconds   int        // From the handle's first attempt to the pass
+    Score     float64
+    Created   time.Time  `orm:"auto_now_add;type(datetime)"`
+}
+
+func (u *Solve) TableName() string {
+    return "solve"
+}
+
+// A handle solves each challenge once
+func (u *Solve) TableUnique() [][]string {
+    return [][]string{{"Handle", "Challenge"}}
+}
+
+// --- Draft Model ---
+// Latest unsubmitted editor content for a challenge, per browser.
+type Draft struct {
+    Id        int        `orm:"auto"`
+    SessionId string     `orm:"size(64);index"` // Owner's draft cookie key
+    Challenge *Challenge `orm:"rel(fk);on_delete(cascade)"`
+    Code      string     `orm:"type(text)"`
+    IpHash    string     `orm:"size(64);index;null"` // Creator, for the per-IP cap
+    Updated   time.Time  `orm:"auto_now;type(datetime);index"`
+}
+
+func (u *Draft) TableName() string {
+    return "draft"
+}
+
+func (u *Draft) TableUnique() [][]string {
+    return [][]string{{"SessionId", "Challenge"}}
+}
+
+// --- Submission Model ---
+// One graded run of a challenge. Results holds the JSON-encoded []CaseOutcome.
+type Submission struct {
+    Id        int        `orm:"auto"`
+    Challenge *Challenge `orm:"rel(fk);on_delete(cascade)"`
+    Code      string     `orm:"type(text)"`
+    Results   string     `orm:"type(text)"`
+    Output    string     `orm:"type(text)"`
+    Passed    bool
+    Score     float64
+    Duration  int        // Milliseconds from request to graded result
+    HintsUsed int        `orm:"default(0)"`
+    Version   int        `orm:"default(1)"` // Challenge version graded against
+    Handle    *Handle    `orm:"rel(fk);null;on_delete(set_null)"`
+    IpHash    string     `orm:"size(64)"`
+    UserAgent string     `orm:"size(255)"`
+    Created   time.Time  `orm:"auto_now_add;type(datetime)"`
+}
+
+func (u *Submission) TableName() string {
+    return "submission"
+}
+
 // ===================================================================================
 // SECTION 2: DATABASE INITIALIZATION
 // ===================================================================================
 
 func init() {
-    orm.RegisterModel(new(AccessLog), new(Challenge), new(TestCase))
+    orm.RegisterModel(new(AccessLog), new(AppSecret), new(ModerationAction), new(RateLimitEntry), new(Challenge), new(TestCase), new(ChallengeRevision), new(Hint), new(Handle), new(Solve), new(Draft), new(Submission))
     orm.RegisterDriver("postgres", orm.DRPostgres)
+}
 
+// InitDB connects to DATABASE_URL, syncs the schema and seeds challenges.
+// It is called once at startup rather than from init, so the package can be
+// imported (e.g. by unit tests) without a database.
+func InitDB() {
     dbUrl := os.Getenv("DATABASE_URL")
     err := orm.RegisterDataBase("default", "postgres", dbUrl)
     if err != nil {
@@ -88,252 +276,237 @@ func init() {
     }
 
     SeedChallenges()
+    ipHashSalt()
 }
 
 // ===================================================================================
 // SECTION 3: SEEDING LOGIC
 // ===================================================================================
 
+// SeedChallenges upserts every challenge in the `challenges_dir` directory
+// (one file per challenge, see challenge_files.go), matched by slug or, for
+// rows seeded before slugs, by title. Challenges whose file has since been
+// deleted are unpublished rather than removed, so their submissions, solves
+// and revisions stay intact. Rows an admin has edited (AdminOwned) are
+// skipped altogether.
 func SeedChallenges() {
     o := orm.NewOrm()
     fmt.Println("Running Seeder for Challenges...")
 
-    // Definition struct to keep the loop clean
-    type SeedData struct {
-        Title, Desc, Hint, FuncName, Diff, Cat, Code string
-        Tests []TestCase
-    }
-
-    seeds := []SeedData{
-        // 1. EASY
-        {
-            Title:    "Legacy Timestamp Bug",
-            Diff:     "Easy",
-            Cat:      "NCI / Debugging",
-            Hint:     "Integer (Total Milliseconds)",
-            FuncName: "convert_time",
-            Desc: `At NCI we work with large volumes of caption files, many of which use older CEA-608 timing formats. Accurate timestamps are critical because even small miscalculations can throw off caption sync during broadcast.
-
-            This function is meant to convert a raw millisecond count into a standard MM:SS:mmm format, but the current implementation produces incorrect results for values over one minute. Identify the logic bug and fix the output.`,
-            Code:  `def convert_time(ms):
-    """
-    Converts milliseconds to formatted string MM:SS:mmm
-    Args:
-        ms (int): Total milliseconds (e.g., 61500)
-    Returns:
-        str: Formatted string (e.g., "01:05:000")
-    """
-    # BUG: Code fails when ms > 60000. Not sure why?
-    minutes = ms // 60
-    seconds = (ms // 1000) % 60
-    rem_ms = ms % 1000
-    
-    return f"{minutes:02}:{seconds:02}:{rem_ms:03}"`,
-            Tests: []TestCase{
-                // FIX: Removed single quotes. Python print() outputs raw strings.
-                {InputArgs: "65000", ExpectedOutput: "01:05:000"}, 
-                {InputArgs: "125500", ExpectedOutput: "02:05:500"}, 
-            },
-        },
-        // 2. EASY
-        {
-            Title:    "Payment Input Validator",
-            Diff:     "Easy",
-            Cat:      "UG / Validation",
-            Hint:     "String (Currency Amount)",
-            FuncName: "validate_currency",
-            Desc: `At Uncommon Giving, every payment request must be validated before being recorded in the database. Even a small data mistake, like stray characters or improperly formatted decimals, can cause a downstream API failure or ledger mismatch, so validation needs to be strict and fast.
-
-            In this validator function, add the logic to reject negative numbers, non-numeric characters, currency symbols, and values with more than two decimal places.`,
-            Code:  `def validate_currency(amount_str):
-    """
-    Validates if a string is a valid currency amount.
-    Rules:
-    1. Must be a valid number.
-    2. Number must be positive.
-    3. No more than 2 decimal places.
-    
-    Args: amount_str (str)
-    Returns: bool
-    """
-    # TODO: Implement the input validation.
-    return False`,
-            Tests: []TestCase{
-                {InputArgs: "'1'", ExpectedOutput: "True"},
-                {InputArgs: "'10.50'", ExpectedOutput: "True"},
-                {InputArgs: "'-5.00'", ExpectedOutput: "False"},
-                {InputArgs: "'10.555'", ExpectedOutput: "False"},
-                {InputArgs: "'abc'", ExpectedOutput: "False"},
-            },
-        },
-        // 3. MEDIUM
-        {
-            Title:    "FCC Compliance Splitter",
-            Diff:     "Medium",
-            Cat:      "NCI / Strings",
-            Hint:     "String (Raw Caption Text)",
-            FuncName: "wrap_text_lines",
-            Desc: `At NCI we generate caption lines for clients that must adhere to strict FCC rules, including the 32-character line limit. If line wrapping is off by even a few characters, captions can fail QA or break accessibility requirements, so text layout must be predictable and efficient.
-
-            The current implementation sometimes exceeds the character limit or splits words incorrectly. Fix the wrapping logic so lines stay within the limit without breaking words.`,
-            Code:  `def wrap_text_lines(text):
-    """
-    Splits text into lines of max 32 chars without cutting words.
-    Args: text (str)
-    Returns: List[str]
-    """
-    words = text.split()
-    lines = []
-    current_line = ""
-
-    for word in words:
-        # BUG: Output is not compliant to FCC guidelines. Find logic errors and fix.
-        if len(current_line) + len(word) > 32:
-            lines.append(current_line)
-            current_line = word
-        else:
-            current_line += word 
-            
-    lines.append(current_line)
-    return lines`,
-            Tests: []TestCase{
-                {InputArgs: "'This is a test of the emergency broadcast system'", ExpectedOutput: "['This is a test of the emergency', 'broadcast system']"},
-                {InputArgs: "'Federal law requires us to state that this program is funded by the committee to elect.'", ExpectedOutput: "['Federal law requires us to state', 'that this program is funded by', 'the committee to elect.']"},                
-                {InputArgs: "'A short line'", ExpectedOutput: "['A short line']"},
-            },
-        },
-        // 4. MEDIUM
-        {
-            Title:    "The 'Lost Penny' Problem",
-            Diff:     "Medium",
-            Cat:      "UG / FinTech",
-            Hint:     "Int (Total Cents), Int (Recipients)",
-            FuncName: "distribute_pennies",
-            Desc: `At Uncommon Giving, users can distribute a single donation across multiple nonprofits. Behind the scenes, we must ensure every penny is allocated correctly. Because whole cents don't always divide evenly, naïve division can cause rounding errors and “lost pennies,” which is unacceptable in strict financial systems.
-
-            Implement a distribution algorithm that splits total_cents among n recipients so that all pennies are accounted for and the final allocations sum exactly to the original amount.`,
-            Code:  `def distribute_pennies(total_cents, n_charities):
-    """
-    Splits total_cents among n_charities.
-    Args:
-        total_cents (int): Total amount (e.g. 10000)
-        n_charities (int): Number of recipients (e.g. 3)
-    Returns:
-        List[int]: List of amounts that sum exactly to total_cents.
-                   (e.g. [3334, 3333, 3333])
-    """
-    # TODO: Implement fair distribution logic.
-    return []`,
-            Tests: []TestCase{
-                {InputArgs: "10000, 3", ExpectedOutput: "[3334, 3333, 3333]"},
-                {InputArgs: "100, 6", ExpectedOutput: "[17, 17, 17, 17, 16, 16]"},
-            },
-        },
-        // 5. HARD
-        {
-            Title:    "Job Dependency Cascade",
-            Diff:     "Hard",
-            Cat:      "Systems / Graph",
-            Hint:     "Dict {Job: [Dependencies]}, String (Failed Job)",
-            FuncName: "find_impacted_jobs",
-            Desc: `At NCI we contribute to the Django5 Scheduler, which helps us to run and monitor thousands of recurring and dependent jobs. When a job fails, we must cancel all jobs downstream from it to avoid zombie processes, partial updates, and resource leaks. That requires a dependable way to trace dependency chains quickly.
-
-            Implement a resolver that walks the dependency graph and returns all jobs that should be cancelled when a specific parent job fails.`,
-            Code:  `def find_impacted_jobs(deps, failed_job):
-    """
-    Finds all downstream jobs affected by a failure.
-    Args:
-        deps (Dict[str, List[str]]): Key is a job, value is list of jobs that depend on it.
-                                     e.g. {'A': ['B'], 'B': ['C']} means A -> B -> C
-        failed_job (str): The ID of the job that crashed.
-    Returns:
-        List[str]: Sorted list of all impacted jobs (including failed_job).
-    """
-    # TODO: Identify all downstream dependencies.
-    return []`,
-            Tests: []TestCase{
-                {InputArgs: "{'A': ['B'], 'B': ['C'], 'C': []}, 'A'", ExpectedOutput: "['A', 'B', 'C']"},
-                {InputArgs: "{'A': ['B', 'C'], 'B': [], 'C': ['D'], 'D': []}, 'A'", ExpectedOutput: "['A', 'B', 'C', 'D']"},
-            },
-        },
-        // 6. HARD
-        {
-            Title:    "Async Ledger Reconciliation",
-            Diff:     "Hard",
-            Cat:      "UG / Distributed",
-            Hint:     "List[Dict] (Events Stream)",
-            FuncName: "reconcile_ledger",
-            Desc: `At Uncommon Giving we process asynchronous webhook streams from payment processors. Events can arrive out of order, like a reversal before the deposit it references, so the ledger must reconcile itself based on the meaning of the events, not just their arrival order. Balances must always be correct, even under heavy load.
-
-            Implement reconciliation logic that computes the final balance from an unordered event stream, correctly handling deposits, withdrawals, and reversals.`,
-            Code:  `def reconcile_ledger(events):
-    """
-    Calculates final balance from out-of-order stream.
-    Args:
-        events (List[Dict]): List of dicts. 
-            e.g. {"id": 1, "type": "DEPOSIT", "amount": 100}
-                 {"id": 2, "type": "REVERSE", "ref_id": 1}
-    Returns:
-        int: Final Balance
-    """
-    # TODO: Reconcile the ledger.
-    return 0`,
-            Tests: []TestCase{
-                {InputArgs: "[{'id':1, 'type':'DEPOSIT', 'amount':100}, {'id':2, 'type':'WITHDRAW', 'amount':50}]", ExpectedOutput: "50"},
-                {InputArgs: "[{'id':2, 'type':'REVERSE', 'ref_id':1}, {'id':1, 'type':'DEPOSIT', 'amount':100}]", ExpectedOutput: "0"},
-            },
-        },
+    dir := web.AppConfig.DefaultString("challenges_dir", "challenges")
+    files, err := LoadChallengeFiles(dir)
+    if err != nil {
+        fmt.Println("Seed Error:", err)
+        return
     }
 
+    // Optionally prove each reference solution passes its own tests first:
+    // "flag" only warns, "strict" refuses to seed a failing challenge.
+    verifyMode := web.AppConfig.DefaultString("seed_verify", "off")
+    var executor Executor
+    if verifyMode != "off" {
+        executor = NewExecutor()
+    }
+
+    var added, updated, unchanged, kept, archived []string
+    seen := make(map[string]bool)
+
     // --- UPSERT LOGIC ---
-    for _, s := range seeds {
-        c := Challenge{Title: s.Title}
-        
-        // 1. Check existence
-        err := o.Read(&c, "Title")
-
-        // 2. Set/Update fields
-        c.Description = s.Desc
-        c.InputHint = s.Hint
-        c.FunctionName = s.FuncName 
-        c.Difficulty = s.Diff
-        c.Category = s.Cat
-        c.Type = "CODE"
-        c.Language = "python"
-        c.StarterCode = s.Code
-
-        // 3. Persist Challenge
+    for _, f := range files {
+        seen[f.Slug] = true
+        tests := f.TestCases()
+        hints := f.HintRows()
+        want := f.Challenge()
+
+        // 1. Check existence by slug, falling back to the title for rows
+        // seeded before slugs existed
+        c := Challenge{Slug: f.Slug}
+        err := o.Read(&c, "Slug")
+        if err == orm.ErrNoRows {
+            c = Challenge{Title: f.Title}
+            err = o.Read(&c, "Title")
+            if err == nil && c.Slug != "" {
+                err = orm.ErrNoRows
+            }
+        }
+        // Once edited in /admin a row no longer follows its file
+        if err == nil && c.AdminOwned {
+            kept = append(kept, f.Title)
+            continue
+        }
+
+        if executor != nil {
+            if err := VerifyReference(executor, want, tests); err != nil {
+                fmt.Printf("Reference check failed for %s: %v\n", f.Title, err)
+                if verifyMode == "strict" {
+                    continue
+                }
+            }
+        }
+
+        before := c
+        before.TestCases = nil
+
+        // 2. Set/Update fields, keeping the row's id, version and whether
+        // an admin has unpublished it
+        id, version, hash, published := c.Id, c.Version, c.ContentHash, c.Published
+        c = want
+        c.Id, c.Version, c.ContentHash, c.Published = id, version, hash, published
+        contentHash := ContentHash(want, tests)
+
+        // 3. Persist Challenge. The row, its cases and its revisions are
+        // written together, as in ReviseChallenge, so a failure can't leave
+        // a version bumped without its cases
         if err == orm.ErrNoRows {
-            if _, err := o.Insert(&c); err != nil {
-                fmt.Printf("Error inserting %s: %v\n", s.Title, err)
+            c.Id = 0
+            c.Version = 1
+            c.ContentHash = contentHash
+            c.Published = true
+            err := o.DoTx(func(ctx context.Context, tx orm.TxOrmer) error {
+                if _, err := tx.Insert(&c); err != nil {
+                    return err
+                }
+                return saveVersion(tx, &c, tests)
+            })
+            if err != nil {
+                fmt.Printf("Error inserting %s: %v\n", f.Title, err)
                 continue
             }
+            added = append(added, f.Title)
         } else {
-            if _, err := o.Update(&c); err != nil {
-                fmt.Printf("Error updating %s: %v\n", s.Title, err)
+            // Rows seeded before versioning have no revision for the
+            // content their submissions were graded against; record it
+            // before anything replaces it
+            var legacy *ChallengeRevision
+            if c.ContentHash == "" && !o.QueryTable("challenge_revision").Filter("Challenge__Id", c.Id).Filter("Version", c.Version).Exist() {
+                stored := GetVersionTestCases(c.Id, c.Version)
+                legacy = &ChallengeRevision{
+                    Challenge:   &Challenge{Id: c.Id},
+                    Version:     c.Version,
+                    ContentHash: ContentHash(before, stored),
+                    Snapshot:    NewRevisionSnapshot(before, stored).JSON(),
+                }
+            }
+
+            newVersion := false
+            switch {
+            case c.ContentHash == contentHash:
+            case c.ContentHash == "" && legacy != nil && legacy.ContentHash == contentHash:
+                // Seeded before versioning, content unchanged: adopt the hash as is
+                c.ContentHash = contentHash
+            default:
+                c.Version++
+                c.ContentHash = contentHash
+                newVersion = true
+            }
+
+            after := c
+            after.TestCases = nil
+            hintsChanged := !sameHints(GetHints(c.Id), hints)
+            if !newVersion && !hintsChanged && reflect.DeepEqual(before, after) {
+                unchanged = append(unchanged, f.Title)
+                continue
+            }
+
+            err := o.DoTx(func(ctx context.Context, tx orm.TxOrmer) error {
+                if legacy != nil {
+                    if _, err := tx.Insert(legacy); err != nil {
+                        return err
+                    }
+                }
+                if _, err := tx.Update(&c); err != nil {
+                    return err
+                }
+                // 4. Test cases are only written for a new version; the old
+                // version's cases stay for its submissions
+                if newVersion {
+                    return saveVersion(tx, &c, tests)
+                }
+                return nil
+            })
+            if err != nil {
+                fmt.Printf("Error updating %s: %v\n", f.Title, err)
+                continue
+            }
+            if newVersion {
+                updated = append(updated, fmt.Sprintf("%s (v%d)", f.Title, c.Version))
+            } else {
+                updated = append(updated, f.Title)
+            }
+            if !hintsChanged {
                 continue
             }
         }
 
-        // 4. Reset Test Cases (Delete old, Insert new)
-        o.QueryTable("test_case").Filter("challenge_id", c.Id).Delete()
-        
-        var newTests []TestCase
-        for _, t := range s.Tests {
-            t.Challenge = &c
-            newTests = append(newTests, t)
+        // 5. Reset Hints (Delete old, Insert new)
+        o.QueryTable("hint").Filter("challenge_id", c.Id).Delete()
+        for i := range hints {
+            hints[i].Challenge = &c
+        }
+        if len(hints) > 0 {
+            o.InsertMulti(len(hints), hints)
+        }
+    }
+
+    // --- ARCHIVE CHALLENGES WHOSE FILE IS GONE ---
+    // Only seeded rows are touched; challenges created any other way have no
+    // SeedFile, and admin-owned ones no longer follow theirs. Restoring the
+    // file doesn't republish; use /admin/challenges.
+    var seeded []Challenge
+    o.QueryTable("challenge").Exclude("seed_file__isnull", true).Exclude("seed_file", "").Filter("AdminOwned", false).Filter("Published", true).All(&seeded)
+    for _, c := range seeded {
+        if seen[c.Slug] {
+            continue
+        }
+        c.Published = false
+        if _, err := o.Update(&c, "Published"); err != nil {
+            fmt.Printf("Error archiving %s: %v\n", c.Title, err)
+            continue
+        }
+        archived = append(archived, c.Title)
+    }
+
+    fmt.Printf("Smart Seed Complete: %d added, %d updated, %d unchanged, %d admin-owned, %d archived.\n",
+        len(added), len(updated), len(unchanged), len(kept), len(archived))
+    for _, group := range []struct {
+        label  string
+        titles []string
+    }{{"+", added}, {"~", updated}, {"!", kept}, {"-", archived}} {
+        for _, title := range group.titles {
+            fmt.Printf("  %s %s\n", group.label, title)
         }
-        o.InsertMulti(len(newTests), newTests)
     }
-    
-    fmt.Println("Smart Seed Complete.")
+}
+
+// sameHints reports whether the stored hints already match the file's.
+func sameHints(stored, want []Hint) bool {
+    if len(stored) != len(want) {
+        return false
+    }
+    for i := range stored {
+        a, b := stored[i], want[i]
+        a.Id, a.Challenge, b.Id, b.Challenge = 0, nil, 0, nil
+        if a != b {
+            return false
+        }
+    }
+    return true
 }
 
 // ===================================================================================
 // SECTION 4: DATABASE RETRIEVAL LOGIC
 // ===================================================================================
 
+// GetChallenges returns the published challenges shown to visitors.
 func GetChallenges() []Challenge {
+    o := orm.NewOrm()
+    var challenges []Challenge
+    o.QueryTable("challenge").Filter("Published", true).OrderBy("id").All(&challenges)
+    return challenges
+}
+
+// GetAllChallenges includes unpublished drafts, for admin pages and checks.
+func GetAllChallenges() []Challenge {
     o := orm.NewOrm()
     var challenges []Challenge
     o.QueryTable("challenge").OrderBy("id").All(&challenges)
@@ -347,25 +520,173 @@ func GetChallengeById(id int) (Challenge, error) {
     return challenge, err
 }
 
+// GetTestCases returns the cases of the challenge's current version.
 func GetTestCases(challengeId int) []TestCase {
+    c, err := GetChallengeById(challengeId)
+    if err != nil {
+        return nil
+    }
+    return GetVersionTestCases(challengeId, c.Version)
+}
+
+func GetHints(challengeId int) []Hint {
     o := orm.NewOrm()
-    var cases []TestCase
-    o.QueryTable("test_case").Filter("challenge_id", challengeId).All(&cases)
-    return cases
+    var hints []Hint
+    o.QueryTable("hint").Filter("challenge_id", challengeId).OrderBy("position", "id").All(&hints)
+    return hints
+}
+
+// HintPenalty is the total score deduction for having revealed the first
+// used hints of a challenge.
+func HintPenalty(challengeId, used int) float64 {
+    penalty := 0.0
+    for i, h := range GetHints(challengeId) {
+        if i >= used {
+            break
+        }
+        penalty += h.Penalty
+    }
+    return penalty
 }
 
-func GetAccessLogs() []AccessLog {
+// NewSubmission builds the stored record of a graded attempt that started at
+// the given time.
+func NewSubmission(c *Challenge, code string, report GradeReport, ipHash, userAgent string, started time.Time) Submission {
+    caseJSON, _ := json.Marshal(report.Cases)
+    return Submission{
+        Challenge: c,
+        Code:      code,
+        Results:   string(caseJSON),
+        Output:    report.Output,
+        Passed:    report.Passed,
+        Score:     report.Score,
+        Duration:  int(time.Since(started).Milliseconds()),
+        HintsUsed: report.HintsUsed,
+        Version:   c.Version,
+        IpHash:    ipHash,
+        UserAgent: userAgent,
+    }
+}
+
+// AddSubmission stores a graded attempt, and records a Solve the first time
+// a handle passes the challenge.
+func AddSubmission(sub *Submission) error {
     o := orm.NewOrm()
+
+    if len(sub.UserAgent) > 255 {
+        sub.UserAgent = sub.UserAgent[:255]
+    }
+
+    if _, err := o.Insert(sub); err != nil {
+        return err
+    }
+
+    if sub.Passed && sub.Handle != nil {
+        if err := recordSolve(o, sub); err != nil {
+            fmt.Println("Solve not recorded:", err)
+        }
+    }
+    return nil
+}
+
+func GetSubmissionById(id int) (Submission, error) {
+    o := orm.NewOrm()
+    var sub Submission
+    err := o.QueryTable("submission").Filter("Id", id).RelatedSel().One(&sub)
+    return sub, err
+}
+
+// HashIP returns a salted SHA-256 of a client address so visitors can be
+// grouped without storing the raw IP.
+func HashIP(ip string) string {
+    h := sha256.Sum256([]byte(ipHashSalt() + ip))
+    return fmt.Sprintf("%x", h)
+}
+
+// GetAccessLogs returns the newest page of approved entries for the homepage.
+func GetAccessLogs() LogPage {
+    page, _ := GetAccessLogPage("", "", 0)
+    return page
+}
+
+// LogPage is one page of approved access logs, newest first. NextCursor is
+// empty on the last page.
+type LogPage struct {
+    Logs       []AccessLog
+    NextCursor string
+}
+
+// GetAccessLogPage returns up to limit approved entries older than cursor
+// (a NextCursor from an earlier page, or "" for the newest), optionally
+// only those whose name or message contains q. A limit of 0 uses
+// `logs_page_size`; it is capped at 100.
+func GetAccessLogPage(cursor, q string, limit int) (LogPage, error) {
+    if limit <= 0 {
+        limit = web.AppConfig.DefaultInt("logs_page_size", 20)
+    }
+    if limit > 100 {
+        limit = 100
+    }
+
+    cond := orm.NewCondition().And("Status", LogApproved)
+    if cursor != "" {
+        before, err := strconv.Atoi(cursor)
+        if err != nil || before < 1 {
+            return LogPage{}, errors.New("invalid cursor")
+        }
+        cond = cond.And("Id__lt", before)
+    }
+    if q = strings.TrimSpace(q); q != "" {
+        cond = cond.AndCond(orm.NewCondition().Or("Name__icontains", q).Or("Message__icontains", q))
+    }
+    qs := orm.NewOrm().QueryTable("access_log").SetCond(cond)
+
+    // Ids increase with Created, and unlike timestamps they never tie.
+    // One extra row tells us whether there is another page.
     var logs []AccessLog
-    o.QueryTable("access_log").OrderBy("-created").All(&logs)
-    return logs
+    if _, err := qs.OrderBy("-id").Limit(limit + 1).All(&logs); err != nil {
+        return LogPage{}, err
+    }
+
+    page := LogPage{Logs: logs}
+    if len(logs) > limit {
+        page.Logs = logs[:limit]
+        page.NextCursor = strconv.Itoa(page.Logs[limit-1].Id)
+    }
+    return page, nil
 }
 
+// ValidateName checks a visitor-chosen display name, as used by access logs
+// and leaderboard handles.
+func ValidateName(name string) error {
+    if err := validateNameFormat(name); err != nil {
+        return err
+    }
+    return checkContent(name)
+}
+
+func validateNameFormat(name string) error {
+    if strings.TrimSpace(name) == "" {
+        return errors.New("name is required")
+    }
+    if len(name) > 50 {
+        return errors.New("name must be at most 50 characters")
+    }
+    return nil
+}
+
+// AddAccessLog moderates and stores a guestbook entry. Every entry is kept:
+// only approved ones are shown, the rest wait in the admin moderation queue.
+// A RejectedError is returned for flagged content.
 func AddAccessLog(name, message, userAgent string) error {
-    if CheckToxicity(name) || CheckToxicity(message) {
-        return errors.New("input rejected: content detected as toxic or aggressive")
+    if err := validateNameFormat(name); err != nil {
+        return err
     }
 
+    nameVerdict, messageVerdict := ModerateText(name), ModerateText(message)
+    status := LogStatus(nameVerdict, messageVerdict)
+    verdicts, _ := json.Marshal(map[string]Verdict{"name": nameVerdict, "message": messageVerdict})
+
     o := orm.NewOrm()
 
     // Generate Signature
@@ -388,66 +709,34 @@ func AddAccessLog(name, message, userAgent string) error {
     }
 
     log := AccessLog{
-        Name:      name,
-        Message:   message,
-        Signature: sig,
-        Terminal:  terminal,
-        ProcessID: pid,
+        Name:       name,
+        Message:    message,
+        Signature:  sig,
+        Terminal:   terminal,
+        ProcessID:  pid,
+        Status:     status,
+        Moderation: string(verdicts),
     }
 
-    _, err := o.Insert(&log)
-    return err
+    if _, err := o.Insert(&log); err != nil {
+        return err
+    }
+    if status == LogApproved {
+        LogEvents.Publish(log)
+    }
+    if status == LogFlagged {
+        if !nameVerdict.Allowed {
+            return RejectedError{Verdict: nameVerdict}
+        }
+        return RejectedError{Verdict: messageVerdict}
+    }
+    return nil
 }
 
 // ===================================================================================
 // SECTION 5: EXTERNAL API HELPERS
 // ===================================================================================
 
-// Check for toxicity using the Perspective API
-func CheckToxicity(content string) bool {
-    apiKey := os.Getenv("PERSPECTIVE_API_KEY")
-    url := "https://commentanalyzer.googleapis.com/v1alpha1/comments:analyze?key=" + apiKey
-
-    requestBody, err := json.Marshal(map[string]interface{}{
-        "comment": map[string]string{
-            "text": content,
-        },
-        "requestedAttributes": map[string]interface{}{
-            "TOXICITY": map[string]interface{}{},
-        },
-    })
-
-    if err != nil {
-        return false // Handle JSON marshaling error
-    }
-
-    client := &http.Client{
-        Timeout: 5 * time.Second, 
-    }
-
-    resp, err := client.Post(url, "application/json", bytes.NewBuffer(requestBody))
-    if err != nil {
-        return false
-    }
-    defer resp.Body.Close()
-
-    var result struct {
-        AttributeScores struct {
-            Toxicity struct {
-                SummaryScore struct {
-                    Value float64 `json:"value"`
-                } `json:"summaryScore"`
-            } `json:"TOXICITY"`
-        } `json:"attributeScores"`
-    }
-
-    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-        return false
-    }
-
-    return result.AttributeScores.Toxicity.SummaryScore.Value > 0.75
-}
-
 // --- Piston API Payloads ---
 
 type PistonRequest struct {
@@ -464,11 +753,16 @@ type PistonFile struct {
 }
 
 type PistonResponse struct {
-    Run struct {
-        Stdout string `json:"stdout"`
-        Stderr string `json:"stderr"`
-        Code   int    `json:"code"`
-    } `json:"run"`
+    Run PistonRun `json:"run"`
+}
+
+// Timings are in milliseconds; zero when the backend doesn't report them.
+type PistonRun struct {
+    Stdout   string  `json:"stdout"`
+    Stderr   string  `json:"stderr"`
+    Code     int     `json:"code"`
+    WallTime float64 `json:"wall_time"`
+    CpuTime  float64 `json:"cpu_time"`
 }
 
 // --- GitHub API Models ---
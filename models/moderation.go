@@ -0,0 +1,365 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+// ===================================================================================
+// CONTENT MODERATION
+// ===================================================================================
+
+// ErrModeratorUnavailable is returned when a backend could not classify the
+// text at all (missing key, network error, bad response), as opposed to
+// classifying it as acceptable.
+var ErrModeratorUnavailable = errors.New("moderation backend unavailable")
+
+// Verdict is the outcome of moderating one piece of text.
+type Verdict struct {
+    Allowed bool
+    Scores  map[string]float64 // Attribute (e.g. TOXICITY) -> 0-1
+    Flagged []string           // Attributes at or over the threshold, sorted
+    Review  []string           // Borderline attributes, below the threshold but worth a human look
+    Source  string             // Moderator that produced the verdict
+    Error   string             // Set when a backend failed and the fail policy decided
+}
+
+// Moderator classifies user-submitted text.
+type Moderator interface {
+    Moderate(text string) (Verdict, error)
+}
+
+// newVerdict builds a verdict from scores, flagging attributes at or over
+// threshold and marking those at or over review for review.
+func newVerdict(source string, scores map[string]float64, threshold, review float64) Verdict {
+    v := Verdict{Allowed: true, Scores: scores, Source: source}
+    for attr, score := range scores {
+        switch {
+        case score >= threshold:
+            v.Flagged = append(v.Flagged, attr)
+        case score >= review:
+            v.Review = append(v.Review, attr)
+        }
+    }
+    sort.Strings(v.Flagged)
+    sort.Strings(v.Review)
+    v.Allowed = len(v.Flagged) == 0
+    return v
+}
+
+// Borderline reports whether the text was allowed but should be reviewed
+// before it is shown.
+func (v Verdict) Borderline() bool {
+    return v.Allowed && len(v.Review) > 0
+}
+
+// NewModerator builds the pipeline selected by the `moderator` key in
+// app.conf: "perspective", "wordlist", "chain" (default; wordlist first,
+// then Perspective) or "off". Without PERSPECTIVE_API_KEY the chain is just
+// the wordlist. Backend failures are resolved by `moderation_fail`:
+// "closed" (default) rejects, "open" accepts.
+func NewModerator() Moderator {
+    var m Moderator
+    apiKey := os.Getenv("PERSPECTIVE_API_KEY")
+    switch web.AppConfig.DefaultString("moderator", "chain") {
+    case "off":
+        return AllowAll{}
+    case "perspective":
+        m = NewPerspectiveModerator(apiKey)
+    case "wordlist":
+        m = wordlistFromConfig()
+    default:
+        if apiKey == "" {
+            fmt.Println("PERSPECTIVE_API_KEY is not set; moderating with the wordlist only")
+            m = wordlistFromConfig()
+        } else {
+            m = ChainModerator{wordlistFromConfig(), NewPerspectiveModerator(apiKey)}
+        }
+    }
+    return FailPolicy{Moderator: m, Open: web.AppConfig.DefaultString("moderation_fail", "closed") == "open"}
+}
+
+func wordlistFromConfig() Moderator {
+    path := web.AppConfig.DefaultString("moderation_wordlist", "conf/moderation_wordlist.txt")
+    w, err := LoadWordlist(path)
+    if err != nil {
+        fmt.Println("Wordlist not loaded:", err)
+        return NewWordlistModerator(nil)
+    }
+    return w
+}
+
+var (
+    moderatorOnce sync.Once
+    moderatorMu   sync.RWMutex
+    moderator     Moderator
+)
+
+// ModerateText runs text through the configured pipeline. A FailPolicy-wrapped
+// pipeline never returns an error, so the verdict alone decides.
+func ModerateText(text string) Verdict {
+    moderatorOnce.Do(func() {
+        moderatorMu.Lock()
+        if moderator == nil {
+            moderator = NewModerator()
+        }
+        moderatorMu.Unlock()
+    })
+
+    moderatorMu.RLock()
+    m := moderator
+    moderatorMu.RUnlock()
+
+    v, err := m.Moderate(text)
+    if err != nil {
+        // Only reachable with an unwrapped moderator from SetModerator
+        return Verdict{Allowed: false, Source: "error", Error: err.Error()}
+    }
+    return v
+}
+
+// SetModerator replaces the configured pipeline, e.g. with a wordlist for
+// offline use.
+func SetModerator(m Moderator) {
+    moderatorMu.Lock()
+    moderator = m
+    moderatorMu.Unlock()
+}
+
+// RejectedError is returned when moderation refuses user input.
+type RejectedError struct {
+    Verdict Verdict
+}
+
+func (e RejectedError) Error() string {
+    if e.Verdict.Error != "" {
+        return "input rejected: moderation is unavailable, please try again later"
+    }
+    return "input rejected: content detected as " + strings.ToLower(strings.Join(e.Verdict.Flagged, ", "))
+}
+
+// checkContent returns a RejectedError if any of texts is refused.
+func checkContent(texts ...string) error {
+    for _, text := range texts {
+        if v := ModerateText(text); !v.Allowed {
+            return RejectedError{Verdict: v}
+        }
+    }
+    return nil
+}
+
+// --- Fail Policy ---
+
+// FailPolicy turns a backend error into a verdict: accepted when Open,
+// rejected otherwise. The error is kept on Verdict.Error.
+type FailPolicy struct {
+    Moderator Moderator
+    Open      bool
+}
+
+func (f FailPolicy) Moderate(text string) (Verdict, error) {
+    v, err := f.Moderator.Moderate(text)
+    if err == nil {
+        return v, nil
+    }
+    v.Allowed = f.Open
+    v.Error = err.Error()
+    if v.Source == "" {
+        v.Source = "fail-policy"
+    }
+    return v, nil
+}
+
+// AllowAll accepts everything; used when moderation is turned off.
+type AllowAll struct{}
+
+func (AllowAll) Moderate(text string) (Verdict, error) {
+    return Verdict{Allowed: true, Source: "off"}, nil
+}
+
+// --- Chain ---
+
+// ChainModerator asks each moderator in turn and stops at the first
+// rejection. Scores from every moderator consulted are merged, keeping the
+// highest per attribute. If none rejects but one failed, the chain returns
+// that failure so the fail policy decides.
+type ChainModerator []Moderator
+
+func (chain ChainModerator) Moderate(text string) (Verdict, error) {
+    merged := Verdict{Allowed: true, Scores: make(map[string]float64), Source: "chain"}
+    var failure error
+
+    for _, m := range chain {
+        v, err := m.Moderate(text)
+        if err != nil {
+            failure = err
+            continue
+        }
+        for attr, score := range v.Scores {
+            if prev, ok := merged.Scores[attr]; !ok || score > prev {
+                merged.Scores[attr] = score
+            }
+        }
+        merged.Review = append(merged.Review, v.Review...)
+        if !v.Allowed {
+            v.Scores, v.Review = merged.Scores, merged.Review
+            return v, nil
+        }
+    }
+    sort.Strings(merged.Review)
+    return merged, failure
+}
+
+// --- Wordlist ---
+
+// WordlistModerator flags text matching any pattern listed for an attribute.
+// It needs no network access, so it also serves offline and in tests.
+type WordlistModerator struct {
+    Rules map[string][]*regexp.Regexp
+}
+
+// NewWordlistModerator compiles patterns per attribute; patterns are
+// case-insensitive regular expressions. Invalid patterns are skipped.
+func NewWordlistModerator(rules map[string][]string) *WordlistModerator {
+    w := &WordlistModerator{Rules: make(map[string][]*regexp.Regexp)}
+    for attr, patterns := range rules {
+        for _, p := range patterns {
+            re, err := regexp.Compile("(?i)" + p)
+            if err != nil {
+                fmt.Printf("Wordlist pattern %q skipped: %v\n", p, err)
+                continue
+            }
+            w.Rules[strings.ToUpper(attr)] = append(w.Rules[strings.ToUpper(attr)], re)
+        }
+    }
+    return w
+}
+
+// LoadWordlist reads "ATTRIBUTE: pattern" lines. Blank lines and lines
+// starting with # are ignored; a line without an attribute is PROFANITY and
+// matches as a whole word.
+func LoadWordlist(path string) (*WordlistModerator, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    rules := make(map[string][]string)
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        attr, pattern, ok := strings.Cut(line, ":")
+        if !ok || strings.ContainsAny(attr, " \t\\") {
+            attr, pattern = "PROFANITY", `\b`+regexp.QuoteMeta(line)+`\b`
+        }
+        rules[strings.TrimSpace(attr)] = append(rules[strings.TrimSpace(attr)], strings.TrimSpace(pattern))
+    }
+    return NewWordlistModerator(rules), scanner.Err()
+}
+
+func (w *WordlistModerator) Moderate(text string) (Verdict, error) {
+    scores := make(map[string]float64)
+    for attr, patterns := range w.Rules {
+        scores[attr] = 0
+        for _, re := range patterns {
+            if re.MatchString(text) {
+                scores[attr] = 1
+                break
+            }
+        }
+    }
+    return newVerdict("wordlist", scores, 1, 1), nil
+}
+
+// --- Perspective ---
+
+// PerspectiveModerator asks Google's Perspective API to score text for
+// Attributes, flagging any at or over Threshold and sending any at or over
+// ReviewThreshold to review.
+type PerspectiveModerator struct {
+    APIKey          string
+    Attributes      []string
+    Threshold       float64
+    ReviewThreshold float64
+    URL             string
+    client          *http.Client
+}
+
+// NewPerspectiveModerator uses `perspective_attributes`,
+// `perspective_threshold` and `perspective_review_threshold` from app.conf.
+func NewPerspectiveModerator(apiKey string) *PerspectiveModerator {
+    return &PerspectiveModerator{
+        APIKey:          apiKey,
+        Attributes:      web.AppConfig.DefaultStrings("perspective_attributes", []string{"TOXICITY"}),
+        Threshold:       web.AppConfig.DefaultFloat("perspective_threshold", 0.75),
+        ReviewThreshold: web.AppConfig.DefaultFloat("perspective_review_threshold", 0.5),
+        URL:             "https://commentanalyzer.googleapis.com/v1alpha1/comments:analyze",
+        client:          &http.Client{Timeout: 5 * time.Second},
+    }
+}
+
+func (p *PerspectiveModerator) Moderate(text string) (Verdict, error) {
+    if p.APIKey == "" {
+        return Verdict{Source: "perspective"}, fmt.Errorf("%w: PERSPECTIVE_API_KEY is not set", ErrModeratorUnavailable)
+    }
+
+    requested := make(map[string]interface{})
+    for _, attr := range p.Attributes {
+        requested[attr] = map[string]interface{}{}
+    }
+    requestBody, err := json.Marshal(map[string]interface{}{
+        "comment":             map[string]string{"text": text},
+        "requestedAttributes": requested,
+        "doNotStore":          true,
+    })
+    if err != nil {
+        return Verdict{Source: "perspective"}, err
+    }
+
+    resp, err := p.client.Post(p.URL+"?key="+p.APIKey, "application/json", bytes.NewBuffer(requestBody))
+    if err != nil {
+        return Verdict{Source: "perspective"}, fmt.Errorf("%w: %v", ErrModeratorUnavailable, err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return Verdict{Source: "perspective"}, fmt.Errorf("%w: HTTP %d", ErrModeratorUnavailable, resp.StatusCode)
+    }
+
+    var result struct {
+        AttributeScores map[string]struct {
+            SummaryScore struct {
+                Value float64 `json:"value"`
+            } `json:"summaryScore"`
+        } `json:"attributeScores"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+        return Verdict{Source: "perspective"}, fmt.Errorf("%w: %v", ErrModeratorUnavailable, err)
+    }
+
+    scores := make(map[string]float64)
+    for _, attr := range p.Attributes {
+        s, ok := result.AttributeScores[attr]
+        if !ok {
+            return Verdict{Source: "perspective"}, fmt.Errorf("%w: no %s score returned", ErrModeratorUnavailable, attr)
+        }
+        scores[attr] = s.SummaryScore.Value
+    }
+    return newVerdict("perspective", scores, p.Threshold, p.ReviewThreshold), nil
+}
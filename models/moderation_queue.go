@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// ===================================================================================
+// ACCESS LOG MODERATION QUEUE
+// ===================================================================================
+
+// AccessLog.Status values. Only approved entries are shown publicly.
+const (
+    LogPending  = "pending"  // Borderline, or moderation was unavailable; awaiting an admin
+    LogApproved = "approved" // Shown on the homepage
+    LogRejected = "rejected" // Hidden by an admin
+    LogFlagged  = "flagged"  // Refused by moderation; kept for review
+)
+
+// LogStatus decides where a new entry goes from its name and message
+// verdicts. A rejection caused only by moderation being unavailable is
+// queued rather than flagged, since nobody has judged the content yet.
+func LogStatus(verdicts ...Verdict) string {
+    status := LogApproved
+    for _, v := range verdicts {
+        switch {
+        case !v.Allowed && v.Error == "":
+            return LogFlagged
+        case !v.Allowed, v.Borderline():
+            status = LogPending
+        }
+    }
+    return status
+}
+
+// Verdicts decodes the stored moderation verdicts, keyed "name" and
+// "message". Entries from before moderation was recorded have none.
+func (l AccessLog) Verdicts() map[string]Verdict {
+    var verdicts map[string]Verdict
+    json.Unmarshal([]byte(l.Moderation), &verdicts)
+    return verdicts
+}
+
+// GetModerationQueue returns entries with the given status, newest first.
+func GetModerationQueue(status string) []AccessLog {
+    o := orm.NewOrm()
+    var logs []AccessLog
+    o.QueryTable("access_log").Filter("Status", status).OrderBy("-created").All(&logs)
+    return logs
+}
+
+// CountByStatus returns how many entries have each status.
+func CountByStatus() map[string]int {
+    o := orm.NewOrm()
+    counts := make(map[string]int)
+    for _, status := range []string{LogPending, LogFlagged, LogApproved, LogRejected} {
+        n, _ := o.QueryTable("access_log").Filter("Status", status).Count()
+        counts[status] = int(n)
+    }
+    return counts
+}
+
+// ModerateLog applies an admin's action ("approve", "reject" or "delete")
+// to an entry and records who did it.
+func ModerateLog(id int, action, admin string) error {
+    o := orm.NewOrm()
+    log := AccessLog{Id: id}
+    if err := o.Read(&log); err != nil {
+        return err
+    }
+
+    record := ModerationAction{
+        LogId:      id,
+        Action:     action,
+        FromStatus: log.Status,
+        Admin:      admin,
+        Summary:    truncate(log.Name+": "+log.Message, 120),
+    }
+
+    switch action {
+    case "approve", "reject":
+        record.ToStatus = LogApproved
+        if action == "reject" {
+            record.ToStatus = LogRejected
+        }
+        log.Status = record.ToStatus
+        if _, err := o.Update(&log, "Status"); err != nil {
+            return err
+        }
+    case "delete":
+        if _, err := o.Delete(&log); err != nil {
+            return err
+        }
+    default:
+        return fmt.Errorf("unknown action %q", action)
+    }
+
+    if record.ToStatus == LogApproved && record.FromStatus != LogApproved {
+        LogEvents.Publish(log)
+    }
+
+    if _, err := o.Insert(&record); err != nil {
+        return errors.New("action applied but not recorded: " + err.Error())
+    }
+    return nil
+}
+
+// GetModerationActions returns the latest admin decisions, newest first.
+func GetModerationActions(limit int) []ModerationAction {
+    o := orm.NewOrm()
+    var actions []ModerationAction
+    o.QueryTable("moderation_action").OrderBy("-id").Limit(limit).All(&actions)
+    return actions
+}
+
+func truncate(s string, n int) string {
+    r := []rune(s)
+    if len(r) <= n {
+        return s
+    }
+    return string(r[:n-3]) + "..."
+}
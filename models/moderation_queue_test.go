@@ -0,0 +1,140 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+func TestLogStatus(t *testing.T) {
+    allowed := Verdict{Allowed: true}
+    borderline := Verdict{Allowed: true, Review: []string{"TOXICITY"}}
+    flagged := Verdict{Flagged: []string{"TOXICITY"}}
+    unavailable := Verdict{Error: "moderation backend unavailable"}
+
+    tests := []struct {
+        name     string
+        verdicts []Verdict
+        want     string
+    }{
+        {"clean", []Verdict{allowed, allowed}, LogApproved},
+        {"borderline message", []Verdict{allowed, borderline}, LogPending},
+        {"backend down", []Verdict{unavailable, allowed}, LogPending},
+        {"flagged name", []Verdict{flagged, allowed}, LogFlagged},
+        {"flagged beats pending", []Verdict{unavailable, flagged}, LogFlagged},
+    }
+    for _, tt := range tests {
+        if got := LogStatus(tt.verdicts...); got != tt.want {
+            t.Errorf("%s: LogStatus = %q, want %q", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestAddAccessLogQueues(t *testing.T) {
+    clearTables(t, "access_log")
+    defer SetModerator(AllowAll{})
+    SetModerator(FailPolicy{Moderator: ChainModerator{
+        NewWordlistModerator(map[string][]string{"PROFANITY": {`\bheck\b`}}),
+        stubModerator{verdict: newVerdict("stub", map[string]float64{"TOXICITY": 0.6}, 0.75, 0.5)},
+    }})
+
+    var rejected RejectedError
+    if err := AddAccessLog("ada", "what the heck", "go-test"); !errors.As(err, &rejected) {
+        t.Errorf("flagged entry: err = %v, want RejectedError", err)
+    }
+    if err := AddAccessLog("grace", "hello", "go-test"); err != nil {
+        t.Errorf("borderline entry: %v", err)
+    }
+
+    counts := CountByStatus()
+    if counts[LogFlagged] != 1 || counts[LogPending] != 1 || counts[LogApproved] != 0 {
+        t.Errorf("counts = %v, want one flagged and one pending", counts)
+    }
+    pending := GetModerationQueue(LogPending)
+    if len(pending) != 1 || pending[0].Name != "grace" {
+        t.Fatalf("pending queue = %+v", pending)
+    }
+    if v := pending[0].Verdicts()["message"]; !v.Borderline() {
+        t.Errorf("stored message verdict = %+v, want borderline", v)
+    }
+}
+
+func TestModerateLog(t *testing.T) {
+    clearTables(t, "access_log", "moderation_action")
+    o := orm.NewOrm()
+    add := func(status string) int {
+        log := AccessLog{Name: "ada", Message: fmt.Sprintf("%s entry", status), Status: status}
+        if _, err := o.Insert(&log); err != nil {
+            t.Fatal(err)
+        }
+        return log.Id
+    }
+
+    events, cancel := LogEvents.Subscribe()
+    defer cancel()
+
+    pending := add(LogPending)
+    if err := ModerateLog(pending, "approve", "admin"); err != nil {
+        t.Fatal(err)
+    }
+    select {
+    case log := <-events:
+        if log.Id != pending {
+            t.Errorf("published entry %d, want %d", log.Id, pending)
+        }
+    default:
+        t.Error("approving did not publish the entry")
+    }
+
+    // Re-approving an approved entry publishes nothing
+    if err := ModerateLog(pending, "approve", "admin"); err != nil {
+        t.Fatal(err)
+    }
+    if len(events) != 0 {
+        t.Error("re-approval published the entry again")
+    }
+
+    flagged := add(LogFlagged)
+    if err := ModerateLog(flagged, "reject", "mod"); err != nil {
+        t.Fatal(err)
+    }
+    if log := (AccessLog{Id: flagged}); o.Read(&log) != nil || log.Status != LogRejected {
+        t.Errorf("rejected entry status = %q", log.Status)
+    }
+
+    if err := ModerateLog(flagged, "delete", "mod"); err != nil {
+        t.Fatal(err)
+    }
+    if err := o.Read(&AccessLog{Id: flagged}); err != orm.ErrNoRows {
+        t.Errorf("deleted entry still readable: %v", err)
+    }
+
+    if err := ModerateLog(pending, "shred", "admin"); err == nil {
+        t.Error("unknown action accepted")
+    }
+    if err := ModerateLog(9999, "approve", "admin"); err == nil {
+        t.Error("missing entry accepted")
+    }
+
+    actions := GetModerationActions(10)
+    if len(actions) != 4 {
+        t.Fatalf("recorded %d actions, want 4", len(actions))
+    }
+    if a := actions[0]; a.Action != "delete" || a.FromStatus != LogRejected || a.LogId != flagged || a.Admin != "mod" {
+        t.Errorf("latest action = %+v", a)
+    }
+    if a := actions[3]; a.FromStatus != LogPending || a.ToStatus != LogApproved || a.Summary != "ada: pending entry" {
+        t.Errorf("first action = %+v", a)
+    }
+}
+
+func TestTruncate(t *testing.T) {
+    if got := truncate("héllo wörld", 8); got != "héllo..." {
+        t.Errorf("truncate = %q", got)
+    }
+    if got := truncate("short", 8); got != "short" {
+        t.Errorf("truncate = %q", got)
+    }
+}
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// stubModerator returns a fixed verdict or error.
+type stubModerator struct {
+    verdict Verdict
+    err     error
+    calls   *int
+}
+
+func (s stubModerator) Moderate(text string) (Verdict, error) {
+    if s.calls != nil {
+        *s.calls++
+    }
+    return s.verdict, s.err
+}
+
+func TestLoadWordlist(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "wordlist.txt")
+    src := "# comment\n\nheck\nTHREAT: kill (you|u)\ninsult: \\bidiot\\b\n"
+    if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    w, err := LoadWordlist(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        text    string
+        flagged []string
+    }{
+        {"hello there", nil},
+        {"what the HECK", []string{"PROFANITY"}},
+        {"checking in", nil}, // Bare words match whole words only
+        {"I will kill u", []string{"THREAT"}},
+        {"you idiot, heck", []string{"INSULT", "PROFANITY"}},
+    }
+    for _, tt := range tests {
+        v, err := w.Moderate(tt.text)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if !reflect.DeepEqual(v.Flagged, tt.flagged) || v.Allowed != (tt.flagged == nil) {
+            t.Errorf("Moderate(%q) = allowed %v, flagged %v; want %v", tt.text, v.Allowed, v.Flagged, tt.flagged)
+        }
+    }
+
+    if _, err := LoadWordlist(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+        t.Error("missing wordlist loaded without error")
+    }
+}
+
+func TestNewWordlistModeratorSkipsBadPatterns(t *testing.T) {
+    w := NewWordlistModerator(map[string][]string{"spam": {"(unclosed", "buy now"}})
+    if n := len(w.Rules["SPAM"]); n != 1 {
+        t.Fatalf("compiled %d SPAM patterns, want 1", n)
+    }
+    if v, _ := w.Moderate("Buy Now!"); v.Allowed {
+        t.Error("case-insensitive pattern did not match")
+    }
+}
+
+func TestChainModerator(t *testing.T) {
+    clean := stubModerator{verdict: newVerdict("a", map[string]float64{"TOXICITY": 0.2, "INSULT": 0.6}, 0.75, 0.5)}
+    toxic := stubModerator{verdict: newVerdict("b", map[string]float64{"TOXICITY": 0.9}, 0.75, 0.5)}
+    down := stubModerator{err: fmt.Errorf("%w: timeout", ErrModeratorUnavailable)}
+
+    t.Run("stops at the first rejection", func(t *testing.T) {
+        calls := 0
+        v, err := ChainModerator{clean, toxic, stubModerator{calls: &calls}}.Moderate("x")
+        if err != nil || v.Allowed || v.Source != "b" || calls != 0 {
+            t.Errorf("verdict = %+v, err %v, later moderator called %d times", v, err, calls)
+        }
+        if v.Scores["INSULT"] != 0.6 || v.Scores["TOXICITY"] != 0.9 {
+            t.Errorf("scores = %v, want the highest from each moderator", v.Scores)
+        }
+        if !reflect.DeepEqual(v.Review, []string{"INSULT"}) {
+            t.Errorf("review = %v", v.Review)
+        }
+    })
+
+    t.Run("all allow", func(t *testing.T) {
+        v, err := ChainModerator{clean, clean}.Moderate("x")
+        if err != nil || !v.Allowed || !v.Borderline() || v.Source != "chain" {
+            t.Errorf("verdict = %+v, err %v", v, err)
+        }
+    })
+
+    t.Run("a rejection beats a failure", func(t *testing.T) {
+        v, err := ChainModerator{down, toxic}.Moderate("x")
+        if err != nil || v.Allowed {
+            t.Errorf("verdict = %+v, err %v; want a rejection", v, err)
+        }
+    })
+
+    t.Run("failure without a rejection", func(t *testing.T) {
+        if _, err := (ChainModerator{clean, down}).Moderate("x"); !errors.Is(err, ErrModeratorUnavailable) {
+            t.Errorf("err = %v, want ErrModeratorUnavailable", err)
+        }
+    })
+}
+
+func TestFailPolicy(t *testing.T) {
+    down := stubModerator{err: fmt.Errorf("%w: timeout", ErrModeratorUnavailable)}
+    for _, open := range []bool{false, true} {
+        v, err := FailPolicy{Moderator: down, Open: open}.Moderate("x")
+        if err != nil || v.Allowed != open || v.Error == "" || v.Source != "fail-policy" {
+            t.Errorf("open=%v: verdict = %+v, err %v", open, v, err)
+        }
+    }
+
+    ok := stubModerator{verdict: Verdict{Allowed: true, Source: "wordlist"}}
+    if v, _ := (FailPolicy{Moderator: ok}).Moderate("x"); !v.Allowed || v.Error != "" {
+        t.Errorf("verdict = %+v, want it passed through", v)
+    }
+}
+
+func TestPerspectiveModerator(t *testing.T) {
+    var status int
+    var body string
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Query().Get("key") != "k" {
+            t.Errorf("key = %q", r.URL.Query().Get("key"))
+        }
+        w.WriteHeader(status)
+        fmt.Fprint(w, body)
+    }))
+    defer server.Close()
+
+    p := NewPerspectiveModerator("k")
+    p.URL = server.URL
+    p.Attributes = []string{"TOXICITY"}
+    p.Threshold, p.ReviewThreshold = 0.75, 0.5
+
+    score := func(v float64) string {
+        return fmt.Sprintf(`{"attributeScores":{"TOXICITY":{"summaryScore":{"value":%g}}}}`, v)
+    }
+    tests := []struct {
+        name       string
+        status     int
+        body       string
+        wantErr    bool
+        allowed    bool
+        borderline bool
+    }{
+        {name: "clean", status: 200, body: score(0.1), allowed: true},
+        {name: "borderline", status: 200, body: score(0.6), allowed: true, borderline: true},
+        {name: "toxic", status: 200, body: score(0.8)},
+        {name: "HTTP error", status: 500, body: "", wantErr: true},
+        {name: "missing attribute", status: 200, body: `{"attributeScores":{}}`, wantErr: true},
+        {name: "bad JSON", status: 200, body: "{", wantErr: true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            status, body = tt.status, tt.body
+            v, err := p.Moderate("text")
+            if tt.wantErr {
+                if !errors.Is(err, ErrModeratorUnavailable) {
+                    t.Errorf("err = %v, want ErrModeratorUnavailable", err)
+                }
+                return
+            }
+            if err != nil || v.Allowed != tt.allowed || v.Borderline() != tt.borderline {
+                t.Errorf("verdict = %+v, err %v", v, err)
+            }
+        })
+    }
+
+    if _, err := NewPerspectiveModerator("").Moderate("x"); !errors.Is(err, ErrModeratorUnavailable) {
+        t.Errorf("no key: err = %v", err)
+    }
+}
+
+func TestValidateName(t *testing.T) {
+    defer SetModerator(AllowAll{})
+    SetModerator(FailPolicy{Moderator: NewWordlistModerator(map[string][]string{"PROFANITY": {`\bheck\b`}})})
+
+    tests := []struct {
+        name     string
+        wantErr  bool
+        rejected bool
+    }{
+        {name: "ada"},
+        {name: "   ", wantErr: true},
+        {name: strings.Repeat("a", 51), wantErr: true},
+        {name: "heck yeah", wantErr: true, rejected: true},
+    }
+    for _, tt := range tests {
+        err := ValidateName(tt.name)
+        if (err != nil) != tt.wantErr {
+            t.Errorf("ValidateName(%q) = %v, want error %v", tt.name, err, tt.wantErr)
+        }
+        var rejected RejectedError
+        if errors.As(err, &rejected) != tt.rejected {
+            t.Errorf("ValidateName(%q) = %v, want RejectedError %v", tt.name, err, tt.rejected)
+        }
+    }
+}
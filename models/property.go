@@ -0,0 +1,363 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+// ===================================================================================
+// PROPERTY-BASED TEST GENERATION
+// ===================================================================================
+
+// A challenge with a Generator gets extra test cases on every run: the
+// generator produces random inputs and the reference solution supplies the
+// expected output for each, so hard-coding answers to the fixed cases no
+// longer passes.
+//
+// Generators are Python regardless of the challenge language. They define
+// generate(rng), which receives a random.Random and returns the argument
+// tuple for one call (a non-tuple is treated as a single argument):
+//
+//     def generate(rng):
+//         return rng.randint(0, 10**6), rng.randint(1, 50)
+
+// PropertyCount is how many random cases a run of c adds.
+func (c Challenge) PropertyCount() int {
+    if strings.TrimSpace(c.Generator) == "" || strings.TrimSpace(c.ReferenceSolution) == "" {
+        return 0
+    }
+    if c.PropertyCases > 0 {
+        return c.PropertyCases
+    }
+    return web.AppConfig.DefaultInt("property_cases", 20)
+}
+
+// GeneratePropertyCases runs c's generator, then its reference solution, and
+// returns the resulting cases. They are compared the same way as the first
+// fixed case, and together carry the weight of one fixed case.
+func GeneratePropertyCases(executor Executor, c Challenge, fixed []TestCase) ([]TestCase, error) {
+    n := c.PropertyCount()
+    if n == 0 {
+        return nil, nil
+    }
+
+    lang, err := LookupLanguage(c.Language)
+    if err != nil {
+        return nil, err
+    }
+    if lang.Name == "go" {
+        return nil, errors.New("property tests are not supported for Go challenges")
+    }
+
+    inputs, err := generateInputs(executor, c.Generator, n, lang.Name == "python")
+    if err != nil {
+        return nil, fmt.Errorf("generator: %v", err)
+    }
+
+    comparator, compareArg := CompareExact, ""
+    if len(fixed) > 0 && fixed[0].Comparator != "" && fixed[0].Comparator != CompareRegex {
+        comparator, compareArg = fixed[0].Comparator, fixed[0].CompareArg
+    }
+
+    cases := make([]TestCase, len(inputs))
+    for i, input := range inputs {
+        cases[i] = TestCase{
+            InputArgs:  input,
+            Comparator: comparator,
+            CompareArg: compareArg,
+            Weight:     1 / float64(len(inputs)),
+            Class:      ClassCorrectness,
+            Property:   true,
+        }
+    }
+
+    results, err := runHarness(executor, lang, c.ReferenceSolution, c.funcName(), cases)
+    if err != nil {
+        return nil, err
+    }
+
+    for i := range cases {
+        res, ok := results[i]
+        if !ok {
+            return nil, fmt.Errorf("reference solution reported no result for (%s)", cases[i].InputArgs)
+        }
+        if res.Exception != "" {
+            return nil, fmt.Errorf("reference solution failed on (%s):\n%s", cases[i].InputArgs, res.Exception)
+        }
+        cases[i].ExpectedOutput = strings.TrimSpace(res.Output)
+    }
+    return cases, nil
+}
+
+func (c Challenge) funcName() string {
+    if c.FunctionName == "" {
+        return "solve"
+    }
+    return c.FunctionName
+}
+
+// runHarness calls funcName from code once per case in a single harness run.
+func runHarness(executor Executor, lang Language, code, funcName string, cases []TestCase) (map[int]CaseResult, error) {
+    run, err := NewHarnessRun(lang, code, funcName, cases)
+    if err != nil {
+        return nil, err
+    }
+    resp, err := executor.Execute(run.Request)
+    if err != nil {
+        return nil, err
+    }
+    return run.Results(resp.Run.Stdout)
+}
+
+// generateInputs executes the generator and returns n argument lists as
+// source literals: Python reprs for Python challenges, JSON otherwise.
+func generateInputs(executor Executor, generator string, n int, pythonLiterals bool) ([]string, error) {
+    code := strings.NewReplacer(
+        "{{CODE}}", jsonLiteral(generator),
+        "{{COUNT}}", strconv.Itoa(n),
+        "{{SEED}}", strconv.FormatInt(rand.Int63(), 10),
+        "{{PYTHON}}", pythonBool(pythonLiterals),
+    ).Replace(generatorHarness)
+
+    inputs, err := runPythonHelper(executor, code)
+    if err != nil {
+        return nil, err
+    }
+    if len(inputs) != n {
+        return nil, fmt.Errorf("expected %d inputs, got %d", n, len(inputs))
+    }
+    return inputs, nil
+}
+
+// runPythonHelper executes one of the helper programs below, which print a
+// JSON list of argument lists as their last line.
+func runPythonHelper(executor Executor, code string) ([]string, error) {
+    python, err := LookupLanguage("python")
+    if err != nil {
+        return nil, err
+    }
+
+    resp, err := executor.Execute(PistonRequest{
+        Language: python.Runtime,
+        Version:  python.Version,
+        Files:    []PistonFile{{Name: "main.py", Content: code}},
+    })
+    if err != nil {
+        return nil, err
+    }
+    if resp.Run.Code != 0 {
+        return nil, errors.New(strings.TrimSpace(resp.Run.Stderr))
+    }
+
+    lines := strings.Split(strings.TrimSpace(resp.Run.Stdout), "\n")
+    var args []string
+    if err := json.Unmarshal([]byte(lines[len(lines)-1]), &args); err != nil {
+        return nil, fmt.Errorf("malformed output: %v", err)
+    }
+    return args, nil
+}
+
+func pythonBool(b bool) string {
+    if b {
+        return "True"
+    }
+    return "False"
+}
+
+const generatorHarness = `import contextlib, io, json, random
+
+_CODE = {{CODE}}
+_PYTHON = {{PYTHON}}
+
+ns = {"__name__": "__generator__"}
+with contextlib.redirect_stdout(io.StringIO()):
+    exec(compile(_CODE, "generator.py", "exec"), ns)
+
+rng = random.Random({{SEED}})
+fmt = repr if _PYTHON else json.dumps
+inputs = []
+for _ in range({{COUNT}}):
+    args = ns["generate"](rng)
+    if not isinstance(args, tuple):
+        args = (args,)
+    inputs.append(", ".join(fmt(a) for a in args))
+print(json.dumps(inputs))
+`
+
+// --- Shrinking ---
+
+// ShrinkFailure looks for a smaller input than tc's that userCode still gets
+// wrong. Each round asks shrinkHarness for simpler variants of the current
+// input (zeroed or halved numbers, shorter strings and lists, and so on),
+// drops those the reference solution rejects, and moves to the first one
+// the submission fails. It gives up after `property_shrink_rounds` rounds,
+// each costing three executions, and reports false if nothing smaller
+// failed. Shrinking is best effort: executor errors end it early.
+//
+// A variant the reference solution accepts may still fall outside what the
+// generator would produce (an empty list, say), since only the generator
+// knows the input domain.
+func ShrinkFailure(executor Executor, c Challenge, userCode string, tc TestCase) (TestCase, CaseResult, bool) {
+    var found CaseResult
+    shrunk := false
+
+    lang, err := LookupLanguage(c.Language)
+    if err != nil || lang.Name == "go" {
+        return tc, found, false
+    }
+
+    rounds := web.AppConfig.DefaultInt("property_shrink_rounds", 5)
+    for round := 0; round < rounds; round++ {
+        variants, err := runPythonHelper(executor, strings.NewReplacer(
+            "{{ARGS}}", jsonLiteral(tc.InputArgs),
+            "{{PYTHON}}", pythonBool(lang.Name == "python"),
+        ).Replace(shrinkHarness))
+        if err != nil {
+            break
+        }
+
+        // Never trade the input for a longer one, so the shortest failure
+        // stays the shortest
+        var candidates []TestCase
+        for _, v := range variants {
+            if len(v) <= len(tc.InputArgs) && v != tc.InputArgs {
+                candidate := tc
+                candidate.InputArgs = v
+                candidates = append(candidates, candidate)
+            }
+        }
+        if len(candidates) == 0 {
+            break
+        }
+
+        expected, err := runHarness(executor, lang, c.ReferenceSolution, c.funcName(), candidates)
+        if err != nil {
+            break
+        }
+        valid := candidates[:0]
+        for i, candidate := range candidates {
+            if res, ok := expected[i]; ok && res.Exception == "" {
+                candidate.ExpectedOutput = strings.TrimSpace(res.Output)
+                valid = append(valid, candidate)
+            }
+        }
+        if len(valid) == 0 {
+            break
+        }
+
+        actual, err := runHarness(executor, lang, userCode, c.funcName(), valid)
+        if err != nil {
+            break
+        }
+        progressed := false
+        for i, candidate := range valid {
+            if res, ok := actual[i]; ok && !CasePassed(candidate, res) {
+                tc, found, shrunk, progressed = candidate, res, true, true
+                break
+            }
+        }
+        if !progressed {
+            break
+        }
+    }
+    return tc, found, shrunk
+}
+
+// shrinkHarness prints simpler variants of one argument list, simplest
+// first. Each variant changes a single argument; the argument count is kept.
+const shrinkHarness = `import ast, json, math
+
+_PYTHON = {{PYTHON}}
+_ARGS = {{ARGS}}
+_LIMIT = 60
+
+def shrink(v):
+    if isinstance(v, bool):
+        if v:
+            yield False
+    elif isinstance(v, int):
+        if v < 0:
+            yield -v
+        # 0, then ever closer to v: a failing boundary is found by bisection
+        d = v
+        while d != 0:
+            yield v - d
+            d = d // 2 if d > 0 else -(-d // 2)
+    elif isinstance(v, float):
+        if v != 0:
+            yield 0.0
+            if math.isfinite(v):
+                if v != int(v):
+                    yield float(int(v))
+                yield v / 2
+    elif isinstance(v, str):
+        if v:
+            yield ""
+            yield v[:len(v) // 2]
+            yield v[1:]
+            yield v[:-1]
+    elif isinstance(v, (list, tuple)):
+        t = type(v)
+        if v:
+            yield t()
+            yield v[:len(v) // 2]
+            yield v[len(v) // 2:]
+            for i in range(len(v)):
+                yield v[:i] + v[i + 1:]
+            for i, x in enumerate(v):
+                for s in shrink(x):
+                    yield v[:i] + t((s,)) + v[i + 1:]
+    elif isinstance(v, (set, frozenset)):
+        for s in shrink(sorted(v, key=repr)):
+            yield type(v)(s)
+    elif isinstance(v, dict):
+        if v:
+            yield {}
+            for k in v:
+                yield {kk: vv for kk, vv in v.items() if kk != k}
+            for k in v:
+                for s in shrink(v[k]):
+                    d = dict(v)
+                    d[k] = s
+                    yield d
+
+if _PYTHON:
+    fmt = repr
+    args = ast.literal_eval("(" + _ARGS + ",)") if _ARGS.strip() else ()
+else:
+    fmt = json.dumps
+    args = tuple(json.loads("[" + _ARGS + "]"))
+
+seen, variants = {_ARGS}, []
+for i, a in enumerate(args):
+    for s in shrink(a):
+        if len(variants) >= _LIMIT:
+            break
+        text = ", ".join(fmt(x) for x in args[:i] + (s,) + args[i + 1:])
+        if text not in seen:
+            seen.add(text)
+            variants.append(text)
+print(json.dumps(variants))
+`
+
+// shortestFailure returns the index of the failing property case whose
+// input is shortest as source text, which is usually the easiest
+// counterexample to reason about, or -1 if every property case passed.
+func shortestFailure(cases []TestCase, failed func(i int) bool) int {
+    best := -1
+    for i, tc := range cases {
+        if !tc.Property || !failed(i) {
+            continue
+        }
+        if best < 0 || len(tc.InputArgs) < len(cases[best].InputArgs) {
+            best = i
+        }
+    }
+    return best
+}
@@ -0,0 +1,238 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beego/beego/v2/client/orm"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+func TestGenerateInputs(t *testing.T) {
+    executor := pythonExecutor(t)
+    generator := "def generate(rng):\n    print('noise')\n    return [rng.randint(0, 9) for _ in range(3)], 'x', None\n"
+
+    tests := []struct {
+        python bool
+        want   []string // Substrings every input must contain
+    }{
+        {python: true, want: []string{", 'x', None"}},
+        {python: false, want: []string{`, "x", null`}},
+    }
+
+    for _, tt := range tests {
+        inputs, err := generateInputs(executor, generator, 4, tt.python)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if len(inputs) != 4 {
+            t.Fatalf("got %d inputs, want 4", len(inputs))
+        }
+        for _, in := range inputs {
+            for _, s := range tt.want {
+                if !strings.HasPrefix(in, "[") || !strings.Contains(in, s) {
+                    t.Errorf("python=%v: input %q, want a list followed by %q", tt.python, in, s)
+                }
+            }
+        }
+    }
+}
+
+func TestGenerateInputsErrors(t *testing.T) {
+    executor := pythonExecutor(t)
+
+    if _, err := generateInputs(executor, "def generate(rng):\n    raise ValueError('bad generator')\n", 2, true); err == nil || !strings.Contains(err.Error(), "bad generator") {
+        t.Errorf("error = %v, want the generator's traceback", err)
+    }
+    if _, err := generateInputs(executor, "x = 1\n", 2, true); err == nil || !strings.Contains(err.Error(), "KeyError") {
+        t.Errorf("error = %v, want a missing generate()", err)
+    }
+}
+
+func TestGeneratePropertyCases(t *testing.T) {
+    executor := pythonExecutor(t)
+    c := Challenge{
+        Language:          "python",
+        FunctionName:      "double",
+        Generator:         "def generate(rng):\n    return rng.randint(-50, 50)\n",
+        ReferenceSolution: "def double(n):\n    return n * 2\n",
+        PropertyCases:     5,
+    }
+
+    cases, err := GeneratePropertyCases(executor, c, []TestCase{{Comparator: CompareFloat, CompareArg: "0.1"}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(cases) != 5 {
+        t.Fatalf("got %d cases, want 5", len(cases))
+    }
+    for _, tc := range cases {
+        n, _ := ParseLiteral(tc.InputArgs)
+        got, _ := ParseLiteral(tc.ExpectedOutput)
+        if got.(float64) != 2*n.(float64) {
+            t.Errorf("case (%s) expects %s", tc.InputArgs, tc.ExpectedOutput)
+        }
+        if !tc.Property || tc.Comparator != CompareFloat || tc.Weight != 0.2 {
+            t.Errorf("case = %+v, want a property case compared like the first fixed case, weighing 1/5", tc)
+        }
+    }
+
+    c.ReferenceSolution = "def double(n):\n    return 1 / 0\n"
+    if _, err := GeneratePropertyCases(executor, c, nil); err == nil || !strings.Contains(err.Error(), "reference solution failed") {
+        t.Errorf("error = %v, want the failing reference reported", err)
+    }
+
+    c.Language = "go"
+    if _, err := GeneratePropertyCases(executor, c, nil); err == nil {
+        t.Error("generated cases for a Go challenge")
+    }
+}
+
+func TestShrinkFailure(t *testing.T) {
+    executor := pythonExecutor(t)
+    defer web.AppConfig.Set("property_shrink_rounds", "")
+
+    sum := Challenge{Language: "python", ReferenceSolution: "def solve(xs):\n    return sum(xs)\n"}
+    maximum := Challenge{Language: "python", ReferenceSolution: "def solve(xs):\n    return max(xs)\n"}
+
+    tests := []struct {
+        name   string
+        c      Challenge
+        code   string
+        input  string
+        rounds string // "" uses the default
+        want   string // "" means nothing smaller fails
+    }{
+        {
+            name:   "drops elements the bug doesn't need",
+            c:      sum,
+            code:   "def solve(xs):\n    return sum(x for x in xs if x < 50)\n",
+            input:  "[3, 99, 7, 120]",
+            rounds: "10",
+            want:   "[50]", // Exactly where the bug starts
+        },
+        {
+            name:   "skips inputs the reference solution rejects",
+            c:      maximum,
+            code:   "def solve(xs):\n    return xs[0]\n",
+            input:  "[1, 5]",
+            want:   "[0, 1]", // Never [], where max() raises
+        },
+        {
+            name:   "already minimal",
+            c:      sum,
+            code:   "def solve(xs):\n    return 0 if xs == [1] else sum(xs)\n",
+            input:  "[1]",
+        },
+        {
+            name:   "disabled",
+            c:      sum,
+            code:   "def solve(xs):\n    return 0\n",
+            input:  "[3, 99, 7, 120]",
+            rounds: "0",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            web.AppConfig.Set("property_shrink_rounds", tt.rounds)
+            tc := TestCase{InputArgs: tt.input, Property: true}
+            got, res, ok := ShrinkFailure(executor, tt.c, tt.code, tc)
+            if tt.want == "" {
+                if ok || got.InputArgs != tt.input {
+                    t.Errorf("shrunk to (%s), want the input kept", got.InputArgs)
+                }
+                return
+            }
+            if !ok || got.InputArgs != tt.want {
+                t.Errorf("shrunk to (%s), ok = %v; want (%s)", got.InputArgs, ok, tt.want)
+            }
+            if CasePassed(got, res) {
+                t.Errorf("shrunk case (%s) passes: expected %s, got %s", got.InputArgs, got.ExpectedOutput, res.Output)
+            }
+        })
+    }
+}
+
+func TestShrinkHarness(t *testing.T) {
+    executor := pythonExecutor(t)
+
+    tests := []struct {
+        args   string
+        python bool
+        want   []string
+    }{
+        {args: "'ab', {'k': 2}", python: true, want: []string{"'', {'k': 2}", "'a', {'k': 2}", "'ab', {}", "'ab', {'k': 0}"}},
+        {args: "(1, 2), {3}", python: true, want: []string{"(), {3}", "(1,), {3}", "(0, 2), {3}", "(1, 2), set()"}},
+        {args: "-8, 2.5", python: true, want: []string{"8, 2.5", "0, 2.5", "-4, 2.5", "-8, 0.0", "-8, 2.0"}},
+        {args: `"ab", true, [1]`, want: []string{`"", true, [1]`, `"ab", false, [1]`, `"ab", true, []`, `"ab", true, [0]`}},
+    }
+
+    for _, tt := range tests {
+        variants, err := runPythonHelper(executor, strings.NewReplacer(
+            "{{ARGS}}", jsonLiteral(tt.args),
+            "{{PYTHON}}", pythonBool(tt.python),
+        ).Replace(shrinkHarness))
+        if err != nil {
+            t.Fatal(err)
+        }
+        seen := make(map[string]bool)
+        for _, v := range variants {
+            if v == tt.args || seen[v] {
+                t.Errorf("(%s): variant %q repeated", tt.args, v)
+            }
+            seen[v] = true
+        }
+        for _, w := range tt.want {
+            if !seen[w] {
+                t.Errorf("(%s): missing variant %q in %q", tt.args, w, variants)
+            }
+        }
+    }
+}
+
+// TestRunJobShrinksPropertyFailure grades a buggy submission end to end and
+// checks the report shows the minimal counterexample.
+func TestRunJobShrinksPropertyFailure(t *testing.T) {
+    executor := pythonExecutor(t)
+    clearTables(t, "submission", "challenge")
+
+    c := Challenge{
+        Slug:              fmt.Sprintf("sum-%d", time.Now().UnixNano()),
+        Title:             "Sum",
+        Type:              "CODE",
+        Language:          "python",
+        Generator:         "def generate(rng):\n    return [rng.randint(50, 200) for _ in range(rng.randint(1, 3))]\n",
+        ReferenceSolution: "def solve(xs):\n    return sum(xs)\n",
+        PropertyCases:     5,
+        Version:           1,
+    }
+    if _, err := orm.NewOrm().Insert(&c); err != nil {
+        t.Fatal(err)
+    }
+
+    code := "def solve(xs):\n    return sum(x for x in xs if x < 50)\n"
+    cases := []TestCase{{InputArgs: "[1, 2]", ExpectedOutput: "3"}}
+    lang, _ := LookupLanguage("python")
+    run, err := NewHarnessRun(lang, code, "solve", cases)
+    if err != nil {
+        t.Fatal(err)
+    }
+    job := NewRunJob(c, cases, run, code, "203.0.113.7", "go-test")
+
+    events, _ := job.Subscribe()
+    if err := NewJobQueue(executor, 1, 1).Submit(job); err != nil {
+        t.Fatal(err)
+    }
+    got := collect(t, events)
+
+    output, _ := got[len(got)-1].Result["output"].(string)
+    for _, want := range []string{"✗ RANDOM: 0/5 generated inputs passed", "Smallest failing input: ([50])", "Expected: 50"} {
+        if !strings.Contains(output, want) {
+            t.Errorf("output missing %q:\n%s", want, output)
+        }
+    }
+}
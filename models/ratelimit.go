@@ -0,0 +1,194 @@
+package models
+
+import (
+	"container/list"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// ===================================================================================
+// RATE LIMITING
+// ===================================================================================
+
+// RateLimit allows Max hits per fixed Window.
+type RateLimit struct {
+    Max    int
+    Window time.Duration
+}
+
+// ParseRateLimit reads "max/window", e.g. "1/24h" or "5/10m".
+func ParseRateLimit(s string) (RateLimit, error) {
+    max, window, ok := strings.Cut(strings.TrimSpace(s), "/")
+    if !ok {
+        return RateLimit{}, fmt.Errorf("rate limit %q: want max/window", s)
+    }
+    n, err := strconv.Atoi(max)
+    if err != nil || n < 1 {
+        return RateLimit{}, fmt.Errorf("rate limit %q: bad max", s)
+    }
+    d, err := time.ParseDuration(window)
+    if err != nil || d <= 0 {
+        return RateLimit{}, fmt.Errorf("rate limit %q: bad window", s)
+    }
+    return RateLimit{Max: n, Window: d}, nil
+}
+
+// RouteLimit returns the limit configured as `ratelimit_<route>`, falling
+// back to def when unset or invalid.
+func RouteLimit(route, def string) RateLimit {
+    limit, err := ParseRateLimit(web.AppConfig.DefaultString("ratelimit_"+route, def))
+    if err != nil {
+        fmt.Println("Rate limit config:", err)
+        limit, _ = ParseRateLimit(def)
+    }
+    return limit
+}
+
+// RateLimitKey combines a route with a hashed client address, so raw IPs
+// are never stored.
+func RateLimitKey(route, ip string) string {
+    return route + ":" + HashIP(ip)
+}
+
+// RateLimiter counts hits per key. Allow records one and reports whether
+// it's within limit; if not, it also reports how long until the window
+// resets.
+type RateLimiter interface {
+    Allow(key string, limit RateLimit) (bool, time.Duration, error)
+}
+
+// NewRateLimiter builds the backend selected by `ratelimit_backend` in
+// app.conf: "memory" (default; per instance) or "db" (shared, survives
+// restarts).
+func NewRateLimiter() RateLimiter {
+    switch web.AppConfig.DefaultString("ratelimit_backend", "memory") {
+    case "db":
+        return NewDBRateLimiter()
+    default:
+        return NewMemoryRateLimiter(web.AppConfig.DefaultInt("ratelimit_memory_keys", 10000))
+    }
+}
+
+// --- In-Memory ---
+
+// MemoryRateLimiter keeps counters in process. Entries expire with their
+// window, and past Capacity keys the least recently used one is dropped.
+type MemoryRateLimiter struct {
+    Capacity int
+
+    mu      sync.Mutex
+    order   *list.List // Front is most recently used
+    entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+    key     string
+    count   int
+    expires time.Time
+}
+
+func NewMemoryRateLimiter(capacity int) *MemoryRateLimiter {
+    return &MemoryRateLimiter{
+        Capacity: capacity,
+        order:    list.New(),
+        entries:  make(map[string]*list.Element),
+    }
+}
+
+func (m *MemoryRateLimiter) Allow(key string, limit RateLimit) (bool, time.Duration, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    now := time.Now()
+    el, ok := m.entries[key]
+    if !ok || !now.Before(el.Value.(*memoryEntry).expires) {
+        if ok {
+            m.order.Remove(el)
+        }
+        el = m.order.PushFront(&memoryEntry{key: key, expires: now.Add(limit.Window)})
+        m.entries[key] = el
+        m.evict(now)
+    } else {
+        m.order.MoveToFront(el)
+    }
+
+    e := el.Value.(*memoryEntry)
+    e.count++
+    if e.count > limit.Max {
+        return false, e.expires.Sub(now), nil
+    }
+    return true, 0, nil
+}
+
+// evict drops expired entries from the back of the list, then the least
+// recently used ones until within Capacity.
+func (m *MemoryRateLimiter) evict(now time.Time) {
+    for el := m.order.Back(); el != nil; {
+        prev := el.Prev()
+        e := el.Value.(*memoryEntry)
+        if !now.Before(e.expires) || (m.Capacity > 0 && m.order.Len() > m.Capacity) {
+            m.order.Remove(el)
+            delete(m.entries, e.key)
+        } else if m.Capacity <= 0 || m.order.Len() <= m.Capacity {
+            // Recently used entries are rarely expired; stop scanning
+            break
+        }
+        el = prev
+    }
+}
+
+// Len reports how many keys are being tracked.
+func (m *MemoryRateLimiter) Len() int {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return m.order.Len()
+}
+
+// --- Database ---
+
+// DBRateLimiter keeps counters in the rate_limit table, so limits hold
+// across restarts and instances. Expired rows are pruned every
+// dbPruneEvery calls.
+type DBRateLimiter struct {
+    calls atomic.Int64
+}
+
+const dbPruneEvery = 100
+
+func NewDBRateLimiter() *DBRateLimiter {
+    return &DBRateLimiter{}
+}
+
+func (d *DBRateLimiter) Allow(key string, limit RateLimit) (bool, time.Duration, error) {
+    o := orm.NewOrm()
+    now := time.Now()
+
+    if d.calls.Add(1)%dbPruneEvery == 0 {
+        o.QueryTable("rate_limit").Filter("ExpiresAt__lte", now).Delete()
+    }
+
+    // One statement, so concurrent requests can't both see a fresh window
+    var count int
+    var expires time.Time
+    err := o.Raw(`INSERT INTO rate_limit (bucket, count, expires_at) VALUES (?, 1, ?)
+        ON CONFLICT (bucket) DO UPDATE SET
+            count = CASE WHEN rate_limit.expires_at <= ? THEN 1 ELSE rate_limit.count + 1 END,
+            expires_at = CASE WHEN rate_limit.expires_at <= ? THEN EXCLUDED.expires_at ELSE rate_limit.expires_at END
+        RETURNING count, expires_at`,
+        key, now.Add(limit.Window), now, now).QueryRow(&count, &expires)
+    if err != nil {
+        return true, 0, err
+    }
+
+    if count > limit.Max {
+        return false, expires.Sub(now), nil
+    }
+    return true, 0, nil
+}
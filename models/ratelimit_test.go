@@ -0,0 +1,134 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+func TestParseRateLimit(t *testing.T) {
+    tests := []struct {
+        in      string
+        want    RateLimit
+        wantErr bool
+    }{
+        {in: "1/24h", want: RateLimit{Max: 1, Window: 24 * time.Hour}},
+        {in: " 5/10m ", want: RateLimit{Max: 5, Window: 10 * time.Minute}},
+        {in: "100/1s", want: RateLimit{Max: 100, Window: time.Second}},
+        {in: "5", wantErr: true},
+        {in: "0/1m", wantErr: true},
+        {in: "-1/1m", wantErr: true},
+        {in: "x/1m", wantErr: true},
+        {in: "5/forever", wantErr: true},
+        {in: "5/0s", wantErr: true},
+        {in: "", wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.in, func(t *testing.T) {
+            got, err := ParseRateLimit(tt.in)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("ParseRateLimit(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Errorf("ParseRateLimit(%q) = %+v, want %+v", tt.in, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestRouteLimit(t *testing.T) {
+    defer web.AppConfig.Set("ratelimit_test", "")
+
+    web.AppConfig.Set("ratelimit_test", "3/1m")
+    if got := RouteLimit("test", "1/1h"); got != (RateLimit{Max: 3, Window: time.Minute}) {
+        t.Errorf("configured limit = %+v", got)
+    }
+    web.AppConfig.Set("ratelimit_test", "lots")
+    if got := RouteLimit("test", "1/1h"); got != (RateLimit{Max: 1, Window: time.Hour}) {
+        t.Errorf("invalid config: limit = %+v, want the default", got)
+    }
+
+    key := RateLimitKey("run", "203.0.113.7")
+    if key != "run:"+HashIP("203.0.113.7") || key == RateLimitKey("draft", "203.0.113.7") {
+        t.Errorf("RateLimitKey = %q", key)
+    }
+}
+
+func TestMemoryRateLimiterWindow(t *testing.T) {
+    tests := []struct {
+        max   int
+        hits  int
+        allow int // How many of the hits are allowed
+    }{
+        {max: 1, hits: 3, allow: 1},
+        {max: 3, hits: 3, allow: 3},
+        {max: 3, hits: 5, allow: 3},
+    }
+
+    for _, tt := range tests {
+        t.Run(fmt.Sprintf("%d of %d", tt.max, tt.hits), func(t *testing.T) {
+            m := NewMemoryRateLimiter(10)
+            limit := RateLimit{Max: tt.max, Window: time.Hour}
+
+            allowed := 0
+            for i := 0; i < tt.hits; i++ {
+                ok, retry, err := m.Allow("k", limit)
+                if err != nil {
+                    t.Fatal(err)
+                }
+                if ok {
+                    allowed++
+                    continue
+                }
+                if retry <= 0 || retry > time.Hour {
+                    t.Errorf("retry after %v, want within the window", retry)
+                }
+            }
+            if allowed != tt.allow {
+                t.Errorf("allowed %d hits, want %d", allowed, tt.allow)
+            }
+
+            // Other keys have their own counters
+            if ok, _, _ := m.Allow("other", limit); !ok {
+                t.Error("a fresh key was limited")
+            }
+        })
+    }
+}
+
+func TestMemoryRateLimiterExpiry(t *testing.T) {
+    m := NewMemoryRateLimiter(10)
+    limit := RateLimit{Max: 1, Window: 20 * time.Millisecond}
+
+    m.Allow("k", limit)
+    if ok, _, _ := m.Allow("k", limit); ok {
+        t.Fatal("second hit inside the window was allowed")
+    }
+    time.Sleep(30 * time.Millisecond)
+    if ok, _, _ := m.Allow("k", limit); !ok {
+        t.Error("hit after the window expired was limited")
+    }
+}
+
+func TestMemoryRateLimiterCapacity(t *testing.T) {
+    m := NewMemoryRateLimiter(2)
+    limit := RateLimit{Max: 1, Window: time.Hour}
+
+    m.Allow("a", limit)
+    m.Allow("b", limit)
+    m.Allow("a", limit) // Touch a, so b is least recently used
+    m.Allow("c", limit)
+
+    if m.Len() != 2 {
+        t.Fatalf("Len() = %d, want 2", m.Len())
+    }
+    if ok, _, _ := m.Allow("a", limit); ok {
+        t.Error("a was evicted instead of the least recently used key")
+    }
+    if ok, _, _ := m.Allow("b", limit); !ok {
+        t.Error("b should have been evicted and start a fresh window")
+    }
+}
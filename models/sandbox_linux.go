@@ -0,0 +1,28 @@
+//go:build linux
+
+package models
+
+import (
+	"os"
+	"syscall"
+)
+
+// sandboxAttr places the child in its own process group (so the whole tree
+// can be killed on timeout) and in fresh user + network namespaces, which
+// leaves it with nothing but a downed loopback interface.
+func sandboxAttr() (*syscall.SysProcAttr, error) {
+    return &syscall.SysProcAttr{
+        Setpgid:    true,
+        Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET,
+        UidMappings: []syscall.SysProcIDMap{
+            {ContainerID: 0, HostID: os.Getuid(), Size: 1},
+        },
+        GidMappings: []syscall.SysProcIDMap{
+            {ContainerID: 0, HostID: os.Getgid(), Size: 1},
+        },
+    }, nil
+}
+
+func killProcessGroup(pid int) error {
+    return syscall.Kill(-pid, syscall.SIGKILL)
+}
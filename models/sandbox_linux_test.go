@@ -0,0 +1,194 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// localPython returns a LocalExecutor with tight limits, skipping the test
+// when python3 or user namespaces aren't available on this host.
+func localPython(t *testing.T) *LocalExecutor {
+    t.Helper()
+    if _, err := exec.LookPath("python3"); err != nil {
+        t.Skip("python3 not installed")
+    }
+
+    l := &LocalExecutor{
+        Commands:   map[string]LocalCommand{"python": {Argv: []string{"python3", "-I"}, MemoryMB: 256}},
+        Timeout:    2 * time.Second,
+        CPUSeconds: 5,
+        FileKB:     64,
+        MaxOutput:  4096,
+    }
+    if _, err := l.Execute(pythonRequest("pass")); errors.Is(err, ErrExecutorOffline) {
+        t.Skipf("sandbox unavailable: %v", err)
+    }
+    return l
+}
+
+func pythonRequest(code string) PistonRequest {
+    return PistonRequest{Language: "python", Files: []PistonFile{{Name: "main.py", Content: code}}}
+}
+
+func TestLocalExecutor(t *testing.T) {
+    l := localPython(t)
+
+    // Something to (fail to) reach from inside the sandbox
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer listener.Close()
+    port := listener.Addr().(*net.TCPAddr).Port
+
+    tests := []struct {
+        name       string
+        code       string
+        stdin      string
+        wantCode   int
+        wantStdout string
+        wantStderr string
+        maxWall    time.Duration
+    }{
+        {name: "stdout", code: "print('hello')", wantStdout: "hello\n"},
+        {name: "stdin", code: "import sys\nprint(sys.stdin.read().upper())", stdin: "abc", wantStdout: "ABC\n"},
+        {name: "exit code and stderr", code: "import sys\nsys.stderr.write('bad')\nsys.exit(3)", wantCode: 3, wantStderr: "bad"},
+        {
+            name:       "no network",
+            code:       fmt.Sprintf("import socket\ntry:\n    socket.create_connection(('127.0.0.1', %d), timeout=1)\n    print('connected')\nexcept OSError:\n    print('blocked')", port),
+            wantStdout: "blocked\n",
+        },
+        {
+            name:       "memory limit",
+            code:       "try:\n    b = bytearray(512 * 1024 * 1024)\n    print('allocated')\nexcept MemoryError:\n    print('refused')",
+            wantStdout: "refused\n",
+        },
+        {
+            name:       "file size limit",
+            code:       "open('big', 'w').write('x' * 200 * 1024)",
+            wantCode:   1, // Python ignores SIGXFSZ and raises instead
+            wantStderr: "File too large",
+        },
+        {
+            name:       "output cap",
+            code:       "while True:\n    print('y' * 100)",
+            wantCode:   -1,
+            wantStderr: "Output limit exceeded.",
+            maxWall:    2 * time.Second,
+        },
+        {
+            name:       "timeout kills the process group",
+            code:       "import subprocess, time\nsubprocess.Popen(['sleep', '30'])\ntime.sleep(30)",
+            wantCode:   -1,
+            wantStderr: "Execution timed out.",
+            maxWall:    5 * time.Second,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req := pythonRequest(tt.code)
+            req.Stdin = tt.stdin
+
+            start := time.Now()
+            resp, err := l.Execute(req)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if tt.maxWall > 0 && time.Since(start) > tt.maxWall {
+                t.Errorf("took %v, want under %v", time.Since(start), tt.maxWall)
+            }
+
+            run := resp.Run
+            if run.Code != tt.wantCode {
+                t.Errorf("code = %d, want %d (stderr %q)", run.Code, tt.wantCode, run.Stderr)
+            }
+            if tt.wantStdout != "" && run.Stdout != tt.wantStdout {
+                t.Errorf("stdout = %q, want %q", run.Stdout, tt.wantStdout)
+            }
+            if !strings.Contains(run.Stderr, tt.wantStderr) {
+                t.Errorf("stderr = %q, want it to contain %q", run.Stderr, tt.wantStderr)
+            }
+            if len(run.Stdout) > l.MaxOutput {
+                t.Errorf("captured %d bytes of stdout, cap is %d", len(run.Stdout), l.MaxOutput)
+            }
+            if run.WallTime <= 0 {
+                t.Error("no wall time reported")
+            }
+        })
+    }
+}
+
+func TestLocalExecutorStream(t *testing.T) {
+    l := localPython(t)
+
+    var lines []string
+    resp, err := l.ExecuteStream(pythonRequest("print('a')\nprint('b', flush=True)\nprint('c', end='')"), func(line string) {
+        lines = append(lines, line)
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := []string{"a", "b"}; !reflect.DeepEqual(lines, want) {
+        t.Errorf("streamed %q, want %q", lines, want)
+    }
+    if resp.Run.Stdout != "a\nb\nc" {
+        t.Errorf("stdout = %q", resp.Run.Stdout)
+    }
+}
+
+func TestLocalExecutorRejects(t *testing.T) {
+    l := &LocalExecutor{Commands: map[string]LocalCommand{"python": {Argv: []string{"python3"}}}}
+
+    tests := []struct {
+        name string
+        req  PistonRequest
+        want string
+    }{
+        {"unconfigured runtime", PistonRequest{Language: "go", Files: []PistonFile{{Name: "main.go"}}}, `does not support "go"`},
+        {"no files", PistonRequest{Language: "python"}, "no files to execute"},
+    }
+
+    for _, tt := range tests {
+        if _, err := l.Execute(tt.req); err == nil || !strings.Contains(err.Error(), tt.want) {
+            t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+        }
+    }
+}
+
+func TestCappedBuffer(t *testing.T) {
+    overflows := 0
+    var lines []string
+    buf := &cappedBuffer{
+        limit:      10,
+        onOverflow: func() { overflows++ },
+        onLine:     func(line string) { lines = append(lines, line) },
+    }
+
+    for _, chunk := range []string{"ab", "c\nde", "f\n", "ghijkl\n", "more\n"} {
+        if n, err := buf.Write([]byte(chunk)); n != len(chunk) || err != nil {
+            t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+        }
+    }
+
+    if buf.String() != "abc\ndef\ngh" {
+        t.Errorf("kept %q, want the first 10 bytes", buf.String())
+    }
+    if overflows != 1 {
+        t.Errorf("onOverflow called %d times, want 1", overflows)
+    }
+    if want := []string{"abc", "def"}; !reflect.DeepEqual(lines, want) {
+        t.Errorf("lines = %q, want %q", lines, want)
+    }
+
+    buf.note("Output limit exceeded.")
+    if buf.String() != "abc\ndef\ngh\nOutput limit exceeded.\n" {
+        t.Errorf("after note: %q", buf.String())
+    }
+}
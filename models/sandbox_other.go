@@ -0,0 +1,21 @@
+//go:build !linux
+
+package models
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func sandboxAttr() (*syscall.SysProcAttr, error) {
+    return nil, errors.New("local sandbox requires linux namespaces")
+}
+
+func killProcessGroup(pid int) error {
+    p, err := os.FindProcess(pid)
+    if err != nil {
+        return err
+    }
+    return p.Kill()
+}
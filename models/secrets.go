@@ -0,0 +1,67 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// ===================================================================================
+// SERVER SECRETS
+// ===================================================================================
+
+var ipSalt struct {
+    sync.Once
+    value string
+}
+
+// ipHashSalt returns IP_HASH_SALT. Unsalted, a hashed IPv4 address can be
+// reversed by trying all 2^32 of them, so when the variable is unset a
+// random salt is generated once and kept in the app_secret table, where
+// every instance finds the same one.
+func ipHashSalt() string {
+    ipSalt.Do(func() {
+        if ipSalt.value = os.Getenv("IP_HASH_SALT"); ipSalt.value != "" {
+            return
+        }
+        fmt.Println("WARNING: IP_HASH_SALT is not set; using a generated salt stored in the database.")
+
+        salt, err := storedSecret("ip_hash_salt")
+        if err != nil {
+            fmt.Println("WARNING: IP hash salt could not be stored; hashes will change on restart:", err)
+            salt = randomHex(32)
+        }
+        ipSalt.value = salt
+    })
+    return ipSalt.value
+}
+
+// storedSecret returns the named secret, generating it on first use.
+func storedSecret(name string) (string, error) {
+    o := orm.NewOrm()
+    secret := AppSecret{Name: name}
+    if err := o.Read(&secret, "Name"); err == nil {
+        return secret.Value, nil
+    }
+
+    secret.Value = randomHex(32)
+    if _, err := o.Insert(&secret); err != nil {
+        // Another instance may have created it first
+        if o.Read(&secret, "Name") != nil {
+            return "", err
+        }
+    }
+    return secret.Value, nil
+}
+
+func randomHex(n int) string {
+    b := make([]byte, n)
+    if _, err := rand.Read(b); err != nil {
+        panic(fmt.Errorf("crypto/rand unavailable: %v", err))
+    }
+    return hex.EncodeToString(b)
+}
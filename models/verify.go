@@ -0,0 +1,138 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ===================================================================================
+// REFERENCE SOLUTION VERIFICATION
+// ===================================================================================
+
+// VerifyReference runs a challenge's reference solution through the executor
+// against cases and returns an error describing any case it fails.
+func VerifyReference(executor Executor, c Challenge, cases []TestCase) error {
+    switch strings.ToUpper(c.Type) {
+    case TypeMCQ:
+        for _, choice := range c.ChoiceList() {
+            if strings.TrimSpace(choice) == strings.TrimSpace(c.Answer) {
+                return nil
+            }
+        }
+        return errors.New("answer is not one of the choices")
+    case TypeSQL:
+        _, err := runFixtureQuery(c.Fixture, c.ReferenceSolution)
+        return err
+    case TypePredict:
+        return verifyPrediction(executor, c)
+    }
+
+    if strings.TrimSpace(c.ReferenceSolution) == "" {
+        return errors.New("no reference solution")
+    }
+
+    report, err := runCases(executor, c, c.ReferenceSolution, cases)
+    if err != nil {
+        return err
+    }
+    if !report.Passed {
+        return fmt.Errorf("%.1f%% of tests pass:\n%s", report.Score, report.Output)
+    }
+    return nil
+}
+
+// runCases grades code against cases as a CODE submission would, with
+// hidden cases revealed; this output is only for challenge authors.
+func runCases(executor Executor, c Challenge, code string, cases []TestCase) (GradeReport, error) {
+    lang, err := LookupLanguage(c.Language)
+    if err != nil {
+        return GradeReport{}, err
+    }
+
+    run, err := NewHarnessRun(lang, code, c.funcName(), cases)
+    if err != nil {
+        return GradeReport{}, err
+    }
+
+    resp, err := executor.Execute(run.Request)
+    if err != nil {
+        return GradeReport{}, err
+    }
+
+    results, err := run.Results(resp.Run.Stdout)
+    if err != nil {
+        return GradeReport{}, err
+    }
+
+    visible := make([]TestCase, len(cases))
+    for i, tc := range cases {
+        tc.Hidden = false
+        visible[i] = tc
+    }
+    return GradeCases(visible, results, resp.Run), nil
+}
+
+// DryRun grades a challenge's own reference solution or starter code
+// (target "reference" or "starter") so authors can check it before
+// publishing. MCQ and PREDICT challenges have nothing to choose between and
+// check their answer instead.
+func DryRun(executor Executor, c Challenge, cases []TestCase, target string) (GradeReport, error) {
+    code := c.ReferenceSolution
+    if target == "starter" {
+        code = c.StarterCode
+    }
+
+    switch strings.ToUpper(c.Type) {
+    case TypeMCQ, TypePredict:
+        if err := VerifyReference(executor, c, cases); err != nil {
+            return GradeReport{Output: fmt.Sprintf("✗ FAIL: %v\n", err)}, nil
+        }
+        return GradeReport{Passed: true, Score: 100, Output: "✓ PASS: answer checks out.\n"}, nil
+    case TypeSQL:
+        return GradeSQL(c, code)
+    }
+
+    if strings.TrimSpace(code) == "" {
+        return GradeReport{}, fmt.Errorf("no %s code", target)
+    }
+    return runCases(executor, c, code, cases)
+}
+
+// verifyPrediction runs a PREDICT snippet and checks its stdout is the
+// stored answer.
+func verifyPrediction(executor Executor, c Challenge) error {
+    lang, err := LookupLanguage(c.Language)
+    if err != nil {
+        return err
+    }
+
+    resp, err := executor.Execute(PistonRequest{
+        Language: lang.Runtime,
+        Version:  lang.Version,
+        Files:    []PistonFile{{Name: lang.FileName, Content: c.StarterCode}},
+    })
+    if err != nil {
+        return err
+    }
+
+    if normalizeOutput(resp.Run.Stdout) != normalizeOutput(c.Answer) {
+        return fmt.Errorf("snippet prints:\n%s", resp.Run.Stdout+resp.Run.Stderr)
+    }
+    return nil
+}
+
+// VerifyChallenges checks every stored challenge's reference solution and
+// prints a line per challenge. It returns the number that failed.
+func VerifyChallenges(executor Executor) int {
+    failed := 0
+    for _, c := range GetAllChallenges() {
+        if err := VerifyReference(executor, c, GetTestCases(c.Id)); err != nil {
+            failed++
+            fmt.Printf("✗ %s: %v\n", c.Title, err)
+        } else {
+            fmt.Printf("✓ %s\n", c.Title)
+        }
+    }
+    return failed
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/beego/beego/v2/client/orm"
+)
+
+// ===================================================================================
+// CHALLENGE VERSIONS
+// ===================================================================================
+
+// RevisionSnapshot is everything that decides how a submission is graded.
+// Its hash is Challenge.ContentHash; display fields such as the title,
+// description and hints are left out so editing them doesn't bump the
+// version.
+type RevisionSnapshot struct {
+    Type              string
+    FunctionName      string
+    Language          string
+    StarterCode       string `json:",omitempty"` // Only graded content for PREDICT
+    ReferenceSolution string
+    Generator         string
+    PropertyCases     int
+    Choices           string
+    Answer            string
+    Fixture           string
+    TestCases         []TestCase
+}
+
+func NewRevisionSnapshot(c Challenge, tests []TestCase) RevisionSnapshot {
+    snap := RevisionSnapshot{
+        Type:              c.Type,
+        FunctionName:      c.FunctionName,
+        Language:          c.Language,
+        ReferenceSolution: c.ReferenceSolution,
+        Generator:         c.Generator,
+        PropertyCases:     c.PropertyCases,
+        Choices:           c.Choices,
+        Answer:            c.Answer,
+        Fixture:           c.Fixture,
+    }
+    if c.Type == TypePredict {
+        snap.StarterCode = c.StarterCode
+    }
+
+    // Ids and versions differ between copies of the same case
+    snap.TestCases = make([]TestCase, len(tests))
+    for i, tc := range tests {
+        tc.Id, tc.Challenge, tc.Version = 0, nil, 0
+        snap.TestCases[i] = tc
+    }
+    return snap
+}
+
+func (s RevisionSnapshot) JSON() string {
+    b, _ := json.Marshal(s)
+    return string(b)
+}
+
+// ContentHash is the hex sha256 of the challenge's graded content.
+func ContentHash(c Challenge, tests []TestCase) string {
+    sum := sha256.Sum256([]byte(NewRevisionSnapshot(c, tests).JSON()))
+    return hex.EncodeToString(sum[:])
+}
+
+// saveVersion stores tests and a revision for c.Version, which the caller
+// has already set. Cases from earlier versions are left in place.
+func saveVersion(o orm.DML, c *Challenge, tests []TestCase) error {
+    for i := range tests {
+        tests[i].Id = 0
+        tests[i].Challenge = c
+        tests[i].Version = c.Version
+    }
+    if len(tests) > 0 {
+        if _, err := o.InsertMulti(len(tests), tests); err != nil {
+            return err
+        }
+    }
+
+    _, err := o.Insert(&ChallengeRevision{
+        Challenge:   c,
+        Version:     c.Version,
+        ContentHash: c.ContentHash,
+        Snapshot:    NewRevisionSnapshot(*c, tests).JSON(),
+    })
+    return err
+}
+
+// GetRevision returns the snapshot a challenge had at version.
+func GetRevision(challengeId, version int) (ChallengeRevision, error) {
+    o := orm.NewOrm()
+    var rev ChallengeRevision
+    err := o.QueryTable("challenge_revision").Filter("Challenge__Id", challengeId).Filter("Version", version).One(&rev)
+    return rev, err
+}
+
+// GetVersionTestCases returns the cases a challenge had at version.
+func GetVersionTestCases(challengeId, version int) []TestCase {
+    o := orm.NewOrm()
+    var cases []TestCase
+    o.QueryTable("test_case").Filter("challenge_id", challengeId).Filter("Version", version).OrderBy("id").All(&cases)
+    return cases
+}
+
+// ReviseChallenge saves c with tests as its full set of cases. A new
+// challenge starts at version 1; an existing one gets a new version only
+// when its graded content changed, otherwise just the row is updated. It
+// reports whether a version was written.
+func ReviseChallenge(c *Challenge, tests []TestCase) (bool, error) {
+    if err := CheckTimedCases(c.Language, tests); err != nil {
+        return false, err
+    }
+
+    o := orm.NewOrm()
+    hash := ContentHash(*c, tests)
+
+    if c.Id != 0 && hash == c.ContentHash {
+        _, err := o.Update(c)
+        return false, err
+    }
+
+    // The row, its cases and the revision are written together, so a
+    // failure can't leave a version bumped without its cases
+    saved := *c
+    err := o.DoTx(func(ctx context.Context, tx orm.TxOrmer) error {
+        if c.Id == 0 {
+            c.Version = 1
+            c.ContentHash = hash
+            if _, err := tx.Insert(c); err != nil {
+                return err
+            }
+        } else {
+            c.Version++
+            c.ContentHash = hash
+            if _, err := tx.Update(c); err != nil {
+                return err
+            }
+        }
+        return saveVersion(tx, c, tests)
+    })
+    if err != nil {
+        *c = saved
+        return false, err
+    }
+    return true, nil
+}
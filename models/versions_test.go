@@ -0,0 +1,167 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/server/web"
+)
+
+func TestReviseChallengeRejectsTimedCases(t *testing.T) {
+    clearTables(t, "challenge")
+    c := Challenge{Slug: "timed-js", Title: "Timed", Type: TypeCode, Language: "javascript"}
+    cases := []TestCase{{InputArgs: "1", ExpectedOutput: "1", Class: ClassPerformance, BudgetMs: 50}}
+
+    if _, err := ReviseChallenge(&c, cases); err == nil || !strings.Contains(err.Error(), "only supported for Python") {
+        t.Fatalf("error = %v, want performance cases refused", err)
+    }
+    if c.Id != 0 {
+        t.Error("challenge was saved")
+    }
+
+    c.Language = "python"
+    if _, err := ReviseChallenge(&c, cases); err != nil {
+        t.Fatal(err)
+    }
+}
+
+// seedFile writes one challenge file into dir and runs the seeder on dir.
+func seedFile(t *testing.T, dir, expected string) {
+    t.Helper()
+    src := "---\ntitle: Seeded\nslug: seeded\nfunction: solve\ntests:\n  - input: \"1\"\n    expected: \"" + expected + "\"\n---\n"
+    if err := os.WriteFile(filepath.Join(dir, "01-seeded.md"), []byte(src), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    SeedChallenges()
+}
+
+func seeded(t *testing.T) Challenge {
+    t.Helper()
+    c := Challenge{Slug: "seeded"}
+    if err := orm.NewOrm().Read(&c, "Slug"); err != nil {
+        t.Fatal(err)
+    }
+    return c
+}
+
+func TestSeedChallengesVersions(t *testing.T) {
+    clearTables(t, "challenge_revision", "test_case", "challenge")
+    dir := t.TempDir()
+    defer web.AppConfig.Set("challenges_dir", "")
+    web.AppConfig.Set("challenges_dir", dir)
+
+    seedFile(t, dir, "1")
+    c := seeded(t)
+    if c.Version != 1 || c.ContentHash == "" {
+        t.Fatalf("new challenge = v%d, hash %q", c.Version, c.ContentHash)
+    }
+
+    seedFile(t, dir, "1")
+    if c := seeded(t); c.Version != 1 {
+        t.Errorf("unchanged file bumped the version to %d", c.Version)
+    }
+
+    seedFile(t, dir, "2")
+    c = seeded(t)
+    if c.Version != 2 {
+        t.Fatalf("changed tests: version %d, want 2", c.Version)
+    }
+    for version, want := range map[int]string{1: "1", 2: "2"} {
+        cases := GetVersionTestCases(c.Id, version)
+        if len(cases) != 1 || cases[0].ExpectedOutput != want {
+            t.Errorf("v%d cases = %+v, want expected %q", version, cases, want)
+        }
+        if _, err := GetRevision(c.Id, version); err != nil {
+            t.Errorf("v%d revision: %v", version, err)
+        }
+    }
+}
+
+func TestSeedChallengesPreVersioningRow(t *testing.T) {
+    dir := t.TempDir()
+    defer web.AppConfig.Set("challenges_dir", "")
+    web.AppConfig.Set("challenges_dir", dir)
+
+    // A row from before versioning: version 1, no hash, no revision
+    legacy := func(expected string) Challenge {
+        clearTables(t, "challenge_revision", "test_case", "challenge")
+        o := orm.NewOrm()
+        c := Challenge{Slug: "seeded", Title: "Seeded", FunctionName: "solve", Type: TypeCode, Language: "python", Version: 1, SeedFile: "01-seeded.md", Published: true}
+        if _, err := o.Insert(&c); err != nil {
+            t.Fatal(err)
+        }
+        tc := TestCase{Challenge: &c, InputArgs: "1", ExpectedOutput: expected, Comparator: CompareExact, Weight: 1, Class: ClassCorrectness, Version: 1}
+        if _, err := o.Insert(&tc); err != nil {
+            t.Fatal(err)
+        }
+        return c
+    }
+
+    t.Run("unchanged content adopts the hash", func(t *testing.T) {
+        legacy("1")
+        seedFile(t, dir, "1")
+        c := seeded(t)
+        if c.Version != 1 || c.ContentHash == "" {
+            t.Errorf("challenge = v%d, hash %q; want v1 with a hash", c.Version, c.ContentHash)
+        }
+        if rev, err := GetRevision(c.Id, 1); err != nil || rev.ContentHash != c.ContentHash {
+            t.Errorf("v1 revision = %+v, %v", rev, err)
+        }
+    })
+
+    t.Run("changed content keeps a snapshot of v1", func(t *testing.T) {
+        legacy("old")
+        seedFile(t, dir, "new")
+        c := seeded(t)
+        if c.Version != 2 {
+            t.Fatalf("version = %d, want 2", c.Version)
+        }
+
+        v1, err := GetRevision(c.Id, 1)
+        if err != nil {
+            t.Fatalf("no v1 revision: %v", err)
+        }
+        if !strings.Contains(v1.Snapshot, `"ExpectedOutput":"old"`) || v1.ContentHash == c.ContentHash {
+            t.Errorf("v1 revision = %+v, want the old content", v1)
+        }
+        if v2, err := GetRevision(c.Id, 2); err != nil || !strings.Contains(v2.Snapshot, `"ExpectedOutput":"new"`) {
+            t.Errorf("v2 revision = %+v, %v", v2, err)
+        }
+    })
+}
+
+func TestSeedChallengesSkipsAdminOwned(t *testing.T) {
+    clearTables(t, "challenge_revision", "test_case", "challenge")
+    dir := t.TempDir()
+    defer web.AppConfig.Set("challenges_dir", "")
+    web.AppConfig.Set("challenges_dir", dir)
+
+    seedFile(t, dir, "1")
+    c := seeded(t)
+    c.Title = "Edited in admin"
+    c.AdminOwned = true
+    if _, err := orm.NewOrm().Update(&c, "Title", "AdminOwned"); err != nil {
+        t.Fatal(err)
+    }
+
+    seedFile(t, dir, "2")
+    c = seeded(t)
+    if c.Version != 1 || c.Title != "Edited in admin" {
+        t.Errorf("admin-owned challenge = v%d %q, want it left alone", c.Version, c.Title)
+    }
+    if cases := GetTestCases(c.Id); len(cases) != 1 || cases[0].ExpectedOutput != "1" {
+        t.Errorf("cases = %+v, want the admin's", cases)
+    }
+
+    // Removing the file doesn't archive it either
+    if err := os.Remove(filepath.Join(dir, "01-seeded.md")); err != nil {
+        t.Fatal(err)
+    }
+    SeedChallenges()
+    if c := seeded(t); !c.Published {
+        t.Error("admin-owned challenge was archived when its file was removed")
+    }
+}
@@ -7,11 +7,36 @@ import (
 )
 
 func init() {
+    beego.InsertFilter("/admin/*", beego.BeforeRouter, controllers.AdminAuth)
+
     beego.Router("/", &controllers.PortfolioController{}, "get:Get")
     beego.Router("/about", &controllers.PortfolioController{}, "get:About")
     beego.Router("/directory", &controllers.PortfolioController{}, "get:Directory")
     beego.Router("/terminal", &controllers.PortfolioController{}, "get:Terminal")
     beego.Router("/challenges", &controllers.PortfolioController{}, "get:Challenge")
     beego.Router("/challenges/run", &controllers.PortfolioController{}, "post:RunCode")
+    beego.Router("/challenges/run/:id", &controllers.PortfolioController{}, "get:RunStatus")
+    beego.Router("/challenges/run/:id/events", &controllers.PortfolioController{}, "get:RunEvents")
+    beego.Router("/challenges/handle", &controllers.PortfolioController{}, "post:ClaimHandle")
+    beego.Router("/challenges/leaderboard", &controllers.PortfolioController{}, "get:Leaderboard")
+    beego.Router("/challenges/certificates/:handle", &controllers.PortfolioController{}, "get:Certificate")
+    beego.Router("/challenges/submissions/:id:int", &controllers.PortfolioController{}, "get:Submission")
+    beego.Router("/logs/stream", &controllers.PortfolioController{}, "get:LogStream")
+    beego.Router("/api/logs", &controllers.PortfolioController{}, "get:LogsJSON")
+    beego.Router("/api/submissions/:id:int", &controllers.PortfolioController{}, "get:SubmissionJSON")
+    beego.Router("/api/challenges/:id:int/hints", &controllers.PortfolioController{}, "get:Hints")
+    beego.Router("/api/challenges/:id:int/hints/next", &controllers.PortfolioController{}, "post:RevealHint")
+    beego.Router("/api/challenges/:id:int/draft", &controllers.PortfolioController{}, "get:Draft;put:SaveDraft;delete:DeleteDraft")
+    beego.Router("/admin/analytics", &controllers.PortfolioController{}, "get:AdminAnalytics")
+    beego.Router("/admin/logs", &controllers.PortfolioController{}, "get:AdminLogs")
+    beego.Router("/admin/logs/:id:int/:action(approve|reject|delete)", &controllers.PortfolioController{}, "post:AdminModerateLog")
+    beego.Router("/admin/challenges", &controllers.PortfolioController{}, "get:AdminChallenges;post:AdminCreateChallenge")
+    beego.Router("/admin/challenges/new", &controllers.PortfolioController{}, "get:AdminNewChallenge")
+    beego.Router("/admin/challenges/:id:int", &controllers.PortfolioController{}, "get:AdminEditChallenge;post:AdminUpdateChallenge")
+    beego.Router("/admin/challenges/:id:int/delete", &controllers.PortfolioController{}, "post:AdminDeleteChallenge")
+    beego.Router("/admin/challenges/:id:int/dryrun", &controllers.PortfolioController{}, "post:AdminDryRun")
+    beego.Router("/admin/challenges/:id:int/cases", &controllers.PortfolioController{}, "post:AdminAddCase")
+    beego.Router("/admin/challenges/:id:int/cases/:case:int", &controllers.PortfolioController{}, "post:AdminUpdateCase")
+    beego.Router("/admin/challenges/:id:int/cases/:case:int/delete", &controllers.PortfolioController{}, "post:AdminDeleteCase")
     beego.Router("/logs/submit", &controllers.PortfolioController{}, "post:SubmitLog")
 }
\ No newline at end of file
@@ -1,5 +1,20 @@
 let editor;
 let currentChallengeId = null;
+let currentChallengeType = 'CODE';
+let currentStarter = '';
+let draftTimer = null;
+let pendingDraft = null;
+
+// Editor settings per Challenge.Language (see models/languages.go)
+const LANGUAGE_MODES = {
+    python:     { mode: 'python',          file: 'SOURCE_CODE.PY', indent: 4, tabs: false },
+    javascript: { mode: 'javascript',      file: 'SOURCE_CODE.JS', indent: 2, tabs: false },
+    typescript: { mode: 'text/typescript', file: 'SOURCE_CODE.TS', indent: 2, tabs: false },
+    go:         { mode: 'go',              file: 'SOURCE_CODE.GO', indent: 4, tabs: true },
+    sql:        { mode: 'text/x-sql',      file: 'QUERY.SQL',      indent: 2, tabs: false }
+};
+
+window.addEventListener('beforeunload', flushDraft);
 
 window.onload = function() {
     editor = CodeMirror.fromTextArea(document.getElementById("code-editor"), {
@@ -9,6 +24,9 @@ window.onload = function() {
         viewportMargin: Infinity,
         indentUnit: 4
     });
+    editor.on('change', (cm, change) => {
+        if (change.origin !== 'setValue') scheduleDraftSave();
+    });
 };
 
 function setTerminalTheme(themeName) {
@@ -40,8 +58,9 @@ function toggleContextPane() {
     }, 310);
 }
 
-function loadChallenge(id, title, desc, starter, lang, diff, btnElement) {
+function loadChallenge(id, title, desc, starter, lang, diff, type, choices, btnElement) {
     currentChallengeId = id;
+    currentChallengeType = (type || 'CODE').toUpperCase();
     
     document.getElementById('welcome-state').style.display = 'none';
     document.getElementById('active-state').style.display = 'block';
@@ -61,8 +80,32 @@ function loadChallenge(id, title, desc, starter, lang, diff, btnElement) {
         diffEl.classList.add('bg-danger', 'bg-opacity-10', 'text-danger');
     }
     
+    const langMode = LANGUAGE_MODES[lang] || LANGUAGE_MODES.python;
+    editor.setOption('mode', langMode.mode);
+    editor.setOption('indentUnit', langMode.indent);
+    editor.setOption('indentWithTabs', langMode.tabs);
+    document.getElementById('source-file-label').innerText =
+        currentChallengeType === 'PREDICT' ? langMode.file.replace('SOURCE_CODE', 'SNIPPET') : langMode.file;
+
+    // MCQ replaces the editor with choices; PREDICT shows the snippet
+    // read-only with a box for the expected output underneath.
+    const isMCQ = currentChallengeType === 'MCQ';
+    document.getElementById('editor-pane').style.display = isMCQ ? 'none' : '';
+    document.getElementById('choice-pane').style.display = isMCQ ? '' : 'none';
+    document.getElementById('prediction-pane').style.display = currentChallengeType === 'PREDICT' ? '' : 'none';
+    document.getElementById('prediction-input').value = '';
+    editor.setOption('readOnly', currentChallengeType === 'PREDICT');
+    renderChoices(isMCQ ? JSON.parse(choices || '[]') : []);
+
+    flushDraft();
+    currentStarter = starter;
     editor.setValue(starter);
+    setTimeout(() => editor.refresh(), 0);
+    document.getElementById('draft-status').innerText = '';
+    loadDraft(id);
+    loadHints(id);
     document.getElementById('run-btn').disabled = false;
+    document.getElementById('reset-btn').disabled = !hasDraft();
     
     document.getElementById('console-output').innerHTML = '<span class="text-secondary">> Module Loaded: ' + id + '</span>';
 
@@ -75,45 +118,274 @@ function loadChallenge(id, title, desc, starter, lang, diff, btnElement) {
     if(btnElement) btnElement.classList.add('active');
 }
 
+// --- Drafts ---
+// Editor content is saved server-side per session a moment after typing
+// stops, and restored the next time the challenge is opened.
+
+const DRAFT_SAVE_DELAY_MS = 1000;
+
+function hasDraft() {
+    return currentChallengeType === 'CODE' || currentChallengeType === 'SQL';
+}
+
+async function loadDraft(id) {
+    if (!hasDraft()) return;
+    try {
+        const response = await fetch(`/api/challenges/${id}/draft`);
+        const data = await response.json();
+        if (data.code !== null && data.challenge_id === currentChallengeId) {
+            editor.setValue(data.code);
+            document.getElementById('draft-status').innerText = 'DRAFT RESTORED';
+        }
+    } catch (e) {
+        // No draft; keep the starter code
+    }
+}
+
+function scheduleDraftSave() {
+    if (!currentChallengeId || !hasDraft()) return;
+    clearTimeout(draftTimer);
+    pendingDraft = { id: currentChallengeId, code: editor.getValue() };
+    draftTimer = setTimeout(flushDraft, DRAFT_SAVE_DELAY_MS);
+}
+
+// Saves any edit still waiting on the autosave delay
+function flushDraft() {
+    clearTimeout(draftTimer);
+    if (pendingDraft) saveDraft(pendingDraft.id, pendingDraft.code);
+    pendingDraft = null;
+}
+
+async function saveDraft(id, code) {
+    const status = document.getElementById('draft-status');
+    try {
+        const response = await fetch(`/api/challenges/${id}/draft`, {
+            method: 'PUT',
+            keepalive: true,
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ code: code })
+        });
+        if (id === currentChallengeId) status.innerText = response.ok ? 'DRAFT SAVED' : 'DRAFT NOT SAVED';
+    } catch (e) {
+        if (id === currentChallengeId) status.innerText = 'DRAFT NOT SAVED';
+    }
+}
+
+async function resetToStarter() {
+    if (!currentChallengeId || !hasDraft()) return;
+    if (!confirm('Discard your draft and restore the starter code?')) return;
+
+    clearTimeout(draftTimer);
+    pendingDraft = null;
+    editor.setValue(currentStarter);
+    document.getElementById('draft-status').innerText = '';
+    await fetch(`/api/challenges/${currentChallengeId}/draft`, { method: 'DELETE' });
+}
+
+// --- Hints ---
+// Revealed hints are tracked server-side per session; each one lowers the
+// score of later submissions.
+
+async function loadHints(id) {
+    document.getElementById('hint-box').style.display = 'none';
+    try {
+        const response = await fetch(`/api/challenges/${id}/hints`);
+        renderHints(await response.json());
+    } catch (e) {
+        // Hints are optional; leave the box hidden
+    }
+}
+
+async function revealHint() {
+    if (!currentChallengeId) return;
+    const response = await fetch(`/api/challenges/${currentChallengeId}/hints/next`, { method: 'POST' });
+    renderHints(await response.json());
+}
+
+function renderHints(data) {
+    if (!data.total || data.challenge_id !== currentChallengeId) return;
+
+    document.getElementById('hint-box').style.display = '';
+    document.getElementById('hint-list').innerHTML = data.revealed
+        .map(h => `<li class="mb-1">${escapeHtml(h.text)} <span class="text-mono x-small opacity-50">-${h.penalty}%</span></li>`)
+        .join('');
+    document.getElementById('hint-count').innerText = `${data.revealed.length}/${data.total}`;
+
+    const btn = document.getElementById('hint-btn');
+    const remaining = data.total - data.revealed.length;
+    btn.disabled = remaining === 0;
+    btn.innerText = remaining === 0 ? 'NO MORE HINTS' : `REVEAL HINT (-${data.next_penalty}%)`;
+}
+
+function renderChoices(choices) {
+    document.getElementById('choice-list').innerHTML = choices.map((choice, i) => `
+        <div class="form-check mb-2">
+            <input class="form-check-input" type="radio" name="mcq-choice" id="mcq-choice-${i}" value="${i}">
+            <label class="form-check-label small" for="mcq-choice-${i}">${escapeHtml(choice)}</label>
+        </div>`).join('');
+}
+
+// Builds the /challenges/run body for the current challenge type, or returns
+// null if there is nothing to submit yet.
+function buildRunPayload() {
+    const payload = { challenge_id: currentChallengeId, user_code: editor.getValue() };
+
+    if (currentChallengeType === 'MCQ') {
+        const picked = document.querySelector('input[name="mcq-choice"]:checked');
+        if (!picked) return null;
+        payload.user_code = '';
+        payload.choice = parseInt(picked.value, 10);
+    } else if (currentChallengeType === 'PREDICT') {
+        payload.user_code = document.getElementById('prediction-input').value;
+    }
+    return payload;
+}
+
 async function runCode() {
     if (!currentChallengeId) return;
 
     const outputDiv = document.getElementById('console-output');
     const runBtn = document.getElementById('run-btn');
-    
+
+    const payload = buildRunPayload();
+    if (!payload) {
+        outputDiv.innerHTML = '<span class="text-danger fw-bold">>> Select an answer first.</span>';
+        return;
+    }
+
     runBtn.disabled = true;
     runBtn.innerHTML = '<span class="spinner-border spinner-border-sm me-2"></span>';
     
-    outputDiv.innerHTML = '<span class="text-accent">> Compiling on remote container...</span>';
-
-    const userCode = editor.getValue();
+    outputDiv.innerHTML = currentChallengeType === 'CODE'
+        ? '<span class="text-accent">> Compiling on remote container...</span>'
+        : '<span class="text-accent">> Grading...</span>';
 
     try {
         const response = await fetch('/challenges/run', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ 
-                challenge_id: currentChallengeId, 
-                user_code: userCode 
-            })
+            body: JSON.stringify(payload)
         });
 
         const data = await response.json();
-        outputDiv.innerHTML = ''; 
 
-        if (data.passed) {
-            outputDiv.innerHTML = `<div class="mb-2"><span class="badge bg-success">PASSED</span></div>` + formatOutput(data.output);
+        if (data.job_id) {
+            outputDiv.innerHTML = '<div class="text-accent">> Job ' + data.job_id + ' queued...</div>';
+            await followJob(data);
         } else {
-            outputDiv.innerHTML = `<div class="mb-2"><span class="badge bg-danger">FAILED</span></div>` + formatOutput(data.output);
+            renderResult(data);
         }
     } catch (e) {
         outputDiv.innerHTML = '<span class="text-danger fw-bold">>> CONNECTION_ERROR</span>';
     } finally {
+        document.getElementById('exec-status').innerText = '';
         runBtn.disabled = false;
         runBtn.innerHTML = '<i class="bi bi-play-fill me-1"></i> RUN';
     }
 }
 
+// Streams per-case progress over SSE, falling back to polling the status
+// endpoint if the stream can't be opened or drops.
+function followJob(job) {
+    return new Promise((resolve) => {
+        const outputDiv = document.getElementById('console-output');
+        const statusEl = document.getElementById('exec-status');
+
+        if (!window.EventSource) {
+            pollJob(job.status_url).then(resolve);
+            return;
+        }
+
+        const source = new EventSource(job.events_url);
+
+        source.addEventListener('status', (e) => {
+            statusEl.innerText = JSON.parse(e.data).status.toUpperCase();
+        });
+
+        source.addEventListener('case', (e) => {
+            outputDiv.insertAdjacentHTML('beforeend', formatProgress(JSON.parse(e.data).case));
+            outputDiv.scrollTop = outputDiv.scrollHeight;
+        });
+
+        source.addEventListener('done', (e) => {
+            source.close();
+            renderResult(JSON.parse(e.data).result);
+            resolve();
+        });
+
+        source.onerror = () => {
+            source.close();
+            pollJob(job.status_url).then(resolve);
+        };
+    });
+}
+
+async function pollJob(url) {
+    const statusEl = document.getElementById('exec-status');
+
+    for (;;) {
+        const response = await fetch(url);
+        const data = await response.json();
+
+        if (!response.ok) {
+            renderResult({ passed: false, output: data.error });
+            return;
+        }
+
+        statusEl.innerText = (data.status || '').toUpperCase();
+        if (data.result) {
+            renderResult(data.result);
+            return;
+        }
+        await new Promise(r => setTimeout(r, 500));
+    }
+}
+
+function renderResult(data) {
+    const outputDiv = document.getElementById('console-output');
+
+    if (data.error) {
+        outputDiv.innerHTML = '<span class="text-danger fw-bold">>> ' + escapeHtml(data.error) + '</span>';
+        return;
+    }
+
+    if (data.passed) {
+        outputDiv.innerHTML = `<div class="mb-2"><span class="badge bg-success">PASSED</span>${formatScore(data.score)}${formatRuntime(data)}${formatPermalink(data.permalink)}</div>` + formatOutput(data.output);
+    } else {
+        outputDiv.innerHTML = `<div class="mb-2"><span class="badge bg-danger">FAILED</span>${formatScore(data.score)}${formatRuntime(data)}${formatPermalink(data.permalink)}</div>` + formatOutput(data.output);
+    }
+}
+
+function formatProgress(c) {
+    const mark = c.passed ? '<span class="text-success fw-bold">✓</span>' : '<span class="text-danger fw-bold">✗</span>';
+    let label = c.hidden ? 'hidden case' : 'Input(' + escapeHtml(c.input) + ')';
+    if (c.property) label = 'random ' + label;
+    if (c.budget_ms) label = `performance case ${c.index + 1}`;
+    const budget = c.budget_ms ? ` / ${c.budget_ms}ms budget` : '';
+    return `<div>${mark} ${label} <span class="opacity-50">${c.ms.toFixed(1)}ms${budget}</span></div>`;
+}
+
+function formatRuntime(data) {
+    if (!data.wall_ms) return '';
+    return `<span class="badge bg-secondary bg-opacity-10 text-secondary text-mono ms-2">${Math.round(data.wall_ms)}ms WALL / ${Math.round(data.cpu_ms)}ms CPU</span>`;
+}
+
+function escapeHtml(text) {
+    const div = document.createElement('div');
+    div.innerText = text || '';
+    return div.innerHTML;
+}
+
+function formatScore(score) {
+    if (typeof score !== 'number') return '';
+    return `<span class="badge bg-secondary bg-opacity-10 text-secondary text-mono ms-2">SCORE ${score}%</span>`;
+}
+
+function formatPermalink(url) {
+    if (!url) return '';
+    return `<a href="${url}" target="_blank" class="text-secondary text-mono x-small ms-2">PERMALINK</a>`;
+}
+
 function formatOutput(rawText) {
     if (!rawText) return '';
     return rawText.replace(/\n/g, '<br/>')
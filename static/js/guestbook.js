@@ -0,0 +1,146 @@
+document.addEventListener('DOMContentLoaded', () => {
+    const scroller = document.getElementById('log-scroll');
+    const body = document.getElementById('log-body');
+    const search = document.getElementById('log-search');
+    const status = document.getElementById('log-status');
+    const streamState = document.getElementById('log-stream-state');
+
+    if (!scroller || !body) return;
+
+    // The server renders the first page; the rest is fetched from /api/logs
+    let cursor = body.dataset.cursor || '';
+    let query = '';
+    let loading = false;
+    let generation = 0; // Bumped per search so stale responses are dropped
+    let searchTimer = null;
+
+    function cell(text, className) {
+        const td = document.createElement('td');
+        td.className = 'p-2 border-bottom border-cream ' + className;
+        td.textContent = text;
+        return td;
+    }
+
+    function renderRow(log) {
+        const tr = document.createElement('tr');
+        tr.dataset.id = log.id;
+        tr.appendChild(cell(log.signature, 'text-accent'));
+        tr.appendChild(cell(log.pid, 'opacity-75'));
+        tr.appendChild(cell(log.timestamp, 'opacity-75'));
+        tr.appendChild(cell(log.name, 'fw-bold'));
+        tr.appendChild(cell(log.message, 'opacity-75'));
+        return tr;
+    }
+
+    function renderEmpty(text) {
+        const tr = document.createElement('tr');
+        tr.className = 'log-empty';
+        const td = document.createElement('td');
+        td.colSpan = 5;
+        td.className = 'p-4 text-center opacity-50';
+        td.textContent = text;
+        tr.appendChild(td);
+        body.appendChild(tr);
+    }
+
+    function nearBottom() {
+        return scroller.scrollTop + scroller.clientHeight >= scroller.scrollHeight - 40;
+    }
+
+    async function fetchPage(reset) {
+        if (loading && !reset) return;
+        if (!reset && !cursor) return;
+
+        const current = ++generation;
+        loading = true;
+        status.textContent = '> loading...';
+
+        const params = new URLSearchParams();
+        if (!reset) params.set('cursor', cursor);
+        if (query) params.set('q', query);
+
+        try {
+            const res = await fetch('/api/logs?' + params.toString());
+            const data = await res.json();
+            if (current !== generation) return;
+            if (!res.ok) throw new Error(data.error || res.statusText);
+
+            if (reset) {
+                body.innerHTML = '';
+                scroller.scrollTop = 0;
+            }
+            data.logs.forEach(log => body.appendChild(renderRow(log)));
+            if (!body.children.length) {
+                renderEmpty(query ? 'No entries match.' : 'Log buffer empty.');
+            }
+
+            cursor = data.next_cursor || '';
+            status.textContent = cursor || !data.logs.length ? '' : '-- end of log --';
+        } catch (err) {
+            if (current !== generation) return;
+            status.textContent = '> read failed: ' + err.message;
+        } finally {
+            if (current === generation) loading = false;
+        }
+
+        // Keep going until the box scrolls or the log runs out
+        if (current === generation && cursor && nearBottom()) {
+            fetchPage(false);
+        }
+    }
+
+    scroller.addEventListener('scroll', () => {
+        if (nearBottom()) fetchPage(false);
+    }, { passive: true });
+
+    if (search) {
+        search.addEventListener('input', () => {
+            clearTimeout(searchTimer);
+            searchTimer = setTimeout(() => {
+                const q = search.value.trim();
+                if (q === query) return;
+                query = q;
+                fetchPage(true);
+            }, 300);
+        });
+    }
+
+    if (cursor && nearBottom()) fetchPage(false);
+
+    // --- Live Updates ---
+
+    function matchesQuery(log) {
+        if (!query) return true;
+        const q = query.toLowerCase();
+        return log.name.toLowerCase().includes(q) || log.message.toLowerCase().includes(q);
+    }
+
+    // Entries approved from the moderation queue can be older than the newest
+    // row, so insert by id rather than always at the top
+    function insertLive(log) {
+        if (!matchesQuery(log) || body.querySelector(`tr[data-id="${log.id}"]`)) return;
+
+        const rows = Array.from(body.querySelectorAll('tr[data-id]'));
+        const before = rows.find(row => Number(row.dataset.id) < log.id);
+        if (!before && cursor) return; // Belongs on a page not loaded yet
+
+        body.querySelectorAll('.log-empty').forEach(row => row.remove());
+        const tr = renderRow(log);
+        tr.classList.add('log-new');
+        body.insertBefore(tr, before || null);
+        setTimeout(() => tr.classList.remove('log-new'), 3000);
+    }
+
+    function setStreamState(text, className) {
+        if (!streamState) return;
+        streamState.textContent = text;
+        streamState.className = 'text-mono x-small ' + className;
+    }
+
+    if (window.EventSource) {
+        const stream = new EventSource('/logs/stream');
+        stream.addEventListener('open', () => setStreamState('● STREAM_ACTIVE', 'text-success'));
+        stream.addEventListener('error', () => setStreamState('○ STREAM_RECONNECTING', 'text-secondary'));
+        stream.addEventListener('log', e => insertLive(JSON.parse(e.data)));
+    }
+});
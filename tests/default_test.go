@@ -9,6 +9,7 @@ import (
 
     "github.com/beego/beego/v2/core/logs"
 
+	"portfolio-site/models"
 	_ "portfolio-site/routers"
 
 	beego "github.com/beego/beego/v2/server/web"
@@ -19,6 +20,7 @@ func init() {
 	_, file, _, _ := runtime.Caller(0)
 	apppath, _ := filepath.Abs(filepath.Dir(filepath.Join(file, ".." + string(filepath.Separator))))
 	beego.TestBeegoInit(apppath)
+	models.InitDB()
 }
 
 